@@ -0,0 +1,163 @@
+// Package ai 为 2048 提供基于 expectimax 搜索的走法建议。
+package ai
+
+import (
+	"math"
+	"math/bits"
+
+	"termiplay/go-backend/game"
+)
+
+// 评估函数中各项的权重
+const (
+	emptyWeight  = 2.7
+	monoWeight   = 1.0
+	smoothWeight = 0.1
+	maxWeight    = 1.0
+)
+
+// minProbability 是继续展开随机节点的最小概率，更不可能出现的局面直接评估
+const minProbability = 0.0001
+
+// BestMove 返回 2048 棋盘 grid 上期望得分最高的方向，没有可以移动的方向时返回 false。
+// 搜索不会修改 grid，调用方可以传入对局棋盘的副本在后台计算。
+func BestMove(grid [][]int) (string, bool) {
+	depth := searchDepth(grid)
+	best, bestScore := "", math.Inf(-1)
+	for _, dir := range game.Directions {
+		next, r := game.Slide(grid, dir)
+		if !r.Moved {
+			continue
+		}
+		score := float64(r.Score) + chance(next, depth, 1)
+		if score > bestScore {
+			best, bestScore = dir, score
+		}
+	}
+	return best, best != ""
+}
+
+// searchDepth 按空格数决定搜索深度，空格越少分支越少，可以看得更远
+func searchDepth(grid [][]int) int {
+	switch n := len(emptyCells(grid)); {
+	case n <= 3:
+		return 3
+	case n <= 8:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// chance 是随机节点：新方块以 0.9 的概率是 2，0.1 的概率是 4，出现在任意空格
+func chance(grid [][]int, depth int, prob float64) float64 {
+	empty := emptyCells(grid)
+	if depth == 0 || len(empty) == 0 || prob < minProbability {
+		return evaluate(grid)
+	}
+
+	total := 0.0
+	for _, p := range empty {
+		for _, tile := range []struct {
+			value int
+			prob  float64
+		}{{2, 0.9}, {4, 0.1}} {
+			grid[p.Y][p.X] = tile.value
+			total += tile.prob * maximize(grid, depth-1, prob*tile.prob/float64(len(empty)))
+		}
+		grid[p.Y][p.X] = 0
+	}
+	return total / float64(len(empty))
+}
+
+// maximize 是玩家节点：选择期望得分最高的方向
+func maximize(grid [][]int, depth int, prob float64) float64 {
+	best := math.Inf(-1)
+	for _, dir := range game.Directions {
+		next, r := game.Slide(grid, dir)
+		if !r.Moved {
+			continue
+		}
+		best = max(best, float64(r.Score)+chance(next, depth, prob))
+	}
+	if math.IsInf(best, -1) {
+		// 无路可走，游戏结束
+		return evaluate(grid)
+	}
+	return best
+}
+
+func emptyCells(grid [][]int) []game.Point {
+	var cells []game.Point
+	for y, row := range grid {
+		for x, v := range row {
+			if v == 0 {
+				cells = append(cells, game.Point{X: x, Y: y})
+			}
+		}
+	}
+	return cells
+}
+
+// evaluate 给局面打分：空格越多、每行每列越单调、相邻方块越接近越好，
+// 最大的方块越大越好。方块的数值都取以 2 为底的对数。
+func evaluate(grid [][]int) float64 {
+	size := len(grid)
+	empty, maxTile := 0, 0
+	smooth := 0.0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			v := rank(grid[y][x])
+			if v == 0 {
+				empty++
+				continue
+			}
+			maxTile = max(maxTile, v)
+			if x+1 < size && grid[y][x+1] != 0 {
+				smooth -= math.Abs(float64(v - rank(grid[y][x+1])))
+			}
+			if y+1 < size && grid[y+1][x] != 0 {
+				smooth -= math.Abs(float64(v - rank(grid[y+1][x])))
+			}
+		}
+	}
+
+	return emptyWeight*float64(empty) +
+		monoWeight*monotonicity(grid) +
+		smoothWeight*smooth +
+		maxWeight*float64(maxTile)
+}
+
+// monotonicity 衡量每行每列朝同一个方向递增或递减的程度，
+// 对每条线取两个方向中违反得较少的一个，结果不大于 0
+func monotonicity(grid [][]int) float64 {
+	size := len(grid)
+	total := 0.0
+	for i := 0; i < size; i++ {
+		var rowInc, rowDec, colInc, colDec float64
+		for k := 0; k+1 < size; k++ {
+			a, b := rank(grid[i][k]), rank(grid[i][k+1])
+			if a > b {
+				rowDec += float64(a - b)
+			} else {
+				rowInc += float64(b - a)
+			}
+			a, b = rank(grid[k][i]), rank(grid[k+1][i])
+			if a > b {
+				colDec += float64(a - b)
+			} else {
+				colInc += float64(b - a)
+			}
+		}
+		total -= min(rowInc, rowDec) + min(colInc, colDec)
+	}
+	return total
+}
+
+// rank 返回方块数值以 2 为底的对数，空格为 0
+func rank(v int) int {
+	if v == 0 {
+		return 0
+	}
+	return bits.Len(uint(v)) - 1
+}
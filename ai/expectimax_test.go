@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"testing"
+
+	"termiplay/go-backend/game"
+)
+
+func TestBestMove(t *testing.T) {
+	tests := []struct {
+		name   string
+		grid   [][]int
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "走不动的棋盘",
+			grid:   [][]int{{2, 4, 2, 4}, {4, 2, 4, 2}, {2, 4, 2, 4}, {4, 2, 4, 2}},
+			wantOK: false,
+		},
+		{
+			name:   "走不动的小棋盘",
+			grid:   [][]int{{2, 4}, {4, 2}},
+			wantOK: false,
+		},
+		{
+			name:   "合并出最大的方块并留在角上",
+			grid:   [][]int{{1024, 1024, 0, 0}, {2, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}},
+			want:   "left",
+			wantOK: true,
+		},
+		{
+			name:   "合并后保持第一行递减",
+			grid:   [][]int{{512, 256, 128, 64}, {4, 8, 16, 64}, {2, 0, 0, 0}, {0, 0, 0, 0}},
+			want:   "up",
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid := game.CloneGrid(tt.grid)
+			got, ok := BestMove(grid)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("BestMove() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+			for y := range grid {
+				for x := range grid[y] {
+					if grid[y][x] != tt.grid[y][x] {
+						t.Fatalf("BestMove changed the grid to %v", grid)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Package bot 实现一个按行交互的协议，让程序通过 SSH 直接驱动游戏引擎，例如
+//
+//	ssh host bot 2048 --seed 42
+//	ssh host bot minesweeper --hard --seed 7
+//
+// 连接建立后服务器先输出一行 JSON，包含初始状态。之后客户端每发送一行命令，
+// 服务器就回复一行 JSON，包含执行后的状态和这一步发生的事件：
+//
+//	{"state": {...}, "events": [{"type": "merge", "at": {"x": 0, "y": 3}, "value": 8}]}
+//
+// 命令无效时回复 {"error": "..."}，局面不变，连接继续。发送 quit 或关闭输入结束连接。
+// 2048 的命令是 left、right、up、down；扫雷的命令是 reveal x y、flag x y 和 chord x y，
+// 坐标从 0 开始，x 是列，y 是行。通过协议玩的对局不计入成绩和排行榜。
+//
+// 协议中的种子和大厅中的种子是分开的，同一个种子在协议中得到的棋局和大厅中
+// 不同，因此不能用协议提前试玩每日挑战。
+package bot
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"termiplay/go-backend/game"
+)
+
+// Point 是事件中的坐标
+type Point struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// Event 描述一步中发生的一件事，例如方块滑动或翻开了一个格子
+type Event struct {
+	Type  string `json:"type"`
+	From  *Point `json:"from,omitempty"`
+	At    *Point `json:"at,omitempty"`
+	Value int    `json:"value"`
+}
+
+// engine 是协议驱动的一局游戏
+type engine interface {
+	// Do 执行一条命令，返回这一步发生的事件
+	Do(cmd string, args []string) ([]Event, error)
+	// State 返回可以编码为 JSON 的当前局面
+	State() any
+}
+
+// response 是服务器回复的一行
+type response struct {
+	State  any     `json:"state,omitempty"`
+	Events []Event `json:"events,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// seedOffset 把协议中的种子移出大厅使用的范围。每日挑战的种子可以由日期算出，
+// 如果协议直接使用玩家给出的种子，就能在协议中提前看到当天所有人共用的雷区
+// 或出块顺序。引擎使用 seedOffset + 种子，局面中报告的仍然是玩家给出的种子
+const seedOffset = 1_000_000_000
+
+// Games 是可以通过协议玩的游戏
+var Games = []string{"2048", "minesweeper"}
+
+// Usage 是 bot 命令参数的简短说明
+const Usage = "<2048 | minesweeper> [--seed N] [--easy | --medium | --hard]"
+
+// Run 按参数 args 开始一局游戏，从 r 读取命令并把回复写到 w，直到 quit 或输入结束
+func Run(args []string, r io.Reader, w io.Writer) error {
+	e, err := newEngine(args)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(response{State: e.State()}); err != nil {
+		return err
+	}
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" {
+			return nil
+		}
+
+		resp := response{}
+		events, err := e.Do(fields[0], fields[1:])
+		if err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.State, resp.Events = e.State(), events
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// newEngine 解析游戏名和参数
+func newEngine(args []string) (engine, error) {
+	if len(args) == 0 {
+		return nil, errors.New("用法: bot " + Usage)
+	}
+
+	name, args := args[0], args[1:]
+	seed := game.NewSeed()
+	var flags []string
+	for i := 0; i < len(args); i++ {
+		if args[i] != "--seed" {
+			flags = append(flags, args[i])
+			continue
+		}
+		if i+1 == len(args) {
+			return nil, errors.New("--seed 后面需要一个数字")
+		}
+		i++
+		n, err := strconv.ParseInt(args[i], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("--seed 后面需要一个数字，而不是 %q", args[i])
+		}
+		if n < 0 || n >= seedOffset {
+			return nil, fmt.Errorf("--seed 必须在 0 到 %d 之间", seedOffset-1)
+		}
+		seed = n
+	}
+
+	switch name {
+	case "2048":
+		if len(flags) > 0 {
+			return nil, fmt.Errorf("未知的参数 %q", flags[0])
+		}
+		return newGame2048(seed), nil
+	case "minesweeper":
+		return newMinesweeper(seed, flags)
+	}
+	return nil, fmt.Errorf("未知的游戏 %q，可选的有 %s", name, strings.Join(Games, ", "))
+}
+
+// coords 解析命令中的 x y 两个坐标
+func coords(cmd string, args []string) (x, y int, err error) {
+	if len(args) != 2 {
+		return 0, 0, fmt.Errorf("用法: %s x y", cmd)
+	}
+	x, errX := strconv.Atoi(args[0])
+	y, errY := strconv.Atoi(args[1])
+	if errX != nil || errY != nil {
+		return 0, 0, fmt.Errorf("坐标必须是整数: %s %s", args[0], args[1])
+	}
+	return x, y, nil
+}
@@ -0,0 +1,204 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// reply 是解码后的一行回复
+type reply struct {
+	State  map[string]any `json:"state"`
+	Events []Event        `json:"events"`
+	Error  string         `json:"error"`
+}
+
+// run 运行一局并解码所有回复，第一行是初始状态
+func run(t *testing.T, args []string, input string) []reply {
+	t.Helper()
+	var out bytes.Buffer
+	if err := Run(args, strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Run(%q) = %v", args, err)
+	}
+	var replies []reply
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var r reply
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("decoding reply %d: %v", len(replies), err)
+		}
+		replies = append(replies, r)
+	}
+	if len(replies) == 0 || replies[0].State == nil {
+		t.Fatalf("Run(%q) did not start with the initial state: %+v", args, replies)
+	}
+	return replies
+}
+
+func TestRunRejectsBadArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"没有游戏", nil, "用法: bot"},
+		{"未知的游戏", []string{"tetris"}, `未知的游戏 "tetris"`},
+		{"种子后面没有数字", []string{"2048", "--seed"}, "--seed 后面需要一个数字"},
+		{"种子不是数字", []string{"2048", "--seed", "x"}, `而不是 "x"`},
+		{"种子是负数", []string{"2048", "--seed", "-1"}, "--seed 必须在 0 到"},
+		{"种子超出范围", []string{"2048", "--seed", "1000000000"}, "--seed 必须在 0 到"},
+		{"2048 不接受难度", []string{"2048", "--hard"}, `未知的参数 "--hard"`},
+		{"未知的难度", []string{"minesweeper", "--insane"}, `未知的参数 "--insane"`},
+		{"难度要带 --", []string{"minesweeper", "hard"}, `未知的参数 "hard"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := Run(tt.args, strings.NewReader(""), &out)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Run(%q) = %v, want an error containing %q", tt.args, err, tt.want)
+			}
+			if out.Len() > 0 {
+				t.Errorf("Run(%q) wrote %q before failing", tt.args, out.String())
+			}
+		})
+	}
+}
+
+func TestRunErrorReplies(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		input string
+		want  string
+	}{
+		{"2048 未知的命令", []string{"2048"}, "reveal 0 0", `未知的命令 "reveal"`},
+		{"2048 命令带参数", []string{"2048"}, "left 1", "left 不接受参数"},
+		{"扫雷未知的命令", []string{"minesweeper"}, "left", `未知的命令 "left"`},
+		{"扫雷缺少坐标", []string{"minesweeper"}, "reveal 0", "用法: reveal x y"},
+		{"扫雷坐标不是整数", []string{"minesweeper"}, "flag a b", "坐标必须是整数"},
+		{"扫雷坐标超出雷区", []string{"minesweeper"}, "chord 9 0", "坐标超出雷区"},
+		{"扫雷不能在数字以外连开", []string{"minesweeper"}, "chord 0 0", "只能在周围旗子数等于数字的格子上连开"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replies := run(t, append(tt.args, "--seed", "1"), tt.input+"\n")
+			if len(replies) != 2 {
+				t.Fatalf("got %d replies, want the initial state and one error", len(replies))
+			}
+			r := replies[1]
+			if !strings.Contains(r.Error, tt.want) {
+				t.Errorf("error = %q, want it to contain %q", r.Error, tt.want)
+			}
+			if r.State != nil || r.Events != nil {
+				t.Errorf("error reply also carried state %v and events %v", r.State, r.Events)
+			}
+		})
+	}
+}
+
+func TestRunInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		replies int
+	}{
+		{"空行被忽略", "\n  \n\t\n", 1},
+		{"quit 之后不再读取", "quit\nleft\n", 1},
+		{"每条命令一行回复", "left\nright\nup\ndown\n", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(run(t, []string{"2048", "--seed", "1"}, tt.input)); got != tt.replies {
+				t.Errorf("got %d replies, want %d", got, tt.replies)
+			}
+		})
+	}
+}
+
+func TestGame2048Events(t *testing.T) {
+	replies := run(t, []string{"2048", "--seed", "42"}, "left\nright\nup\ndown\n")
+	moved := false
+	for _, r := range replies[1:] {
+		if r.Error != "" {
+			if r.Error != "这个方向无法移动" {
+				t.Errorf("unexpected error %q", r.Error)
+			}
+			continue
+		}
+		moved = true
+		if n := len(r.Events); n == 0 || r.Events[n-1].Type != "spawn" {
+			t.Errorf("a move should end with a spawn event, got %+v", r.Events)
+		}
+		for _, e := range r.Events {
+			if e.At == nil || (e.Type == "slide") != (e.From != nil) {
+				t.Errorf("malformed event %+v", e)
+			}
+		}
+	}
+	if !moved {
+		t.Error("none of the four directions moved")
+	}
+}
+
+func TestMinesweeperEvents(t *testing.T) {
+	replies := run(t, []string{"minesweeper", "--easy", "--seed", "7"}, "reveal 4 4\nflag 4 4\n")
+	revealed := replies[1]
+	if revealed.Error != "" {
+		t.Fatalf("reveal failed: %s", revealed.Error)
+	}
+	// 第一次翻开的格子和周围都没有雷，所以是 0 并且会连着翻开一片
+	var first *Event
+	for i, e := range revealed.Events {
+		if e.Type == "reveal" && *e.At == (Point{X: 4, Y: 4}) {
+			first = &revealed.Events[i]
+		}
+	}
+	if first == nil || first.Value != 0 || len(revealed.Events) < 9 {
+		t.Errorf("first reveal events = %+v, want an opening around (4, 4)", revealed.Events)
+	}
+	if board := revealed.State["board"].([]any); board[4].(string)[4] != '0' {
+		t.Errorf("board row 4 = %v, want (4, 4) shown as 0", board[4])
+	}
+
+	if got := replies[2].Error; got != "已经翻开的格子不能插旗" {
+		t.Errorf("flagging a revealed cell replied %+v", replies[2])
+	}
+}
+
+func TestSeedOffset(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		seed int64
+	}{
+		{"2048", []string{"2048", "--seed", "42"}, 42},
+		{"扫雷", []string{"minesweeper", "--seed", "0"}, 0},
+		{"最大的种子", []string{"2048", "--seed", "999999999"}, 999999999},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 引擎用的种子要移出大厅的范围，但局面里报告的还是玩家给的种子
+			e, err := newEngine(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var engineSeed int64
+			switch e := e.(type) {
+			case *game2048:
+				engineSeed = e.g.Seed
+			case *minesweeper:
+				engineSeed = e.ms.Seed
+			}
+			if engineSeed != seedOffset+tt.seed {
+				t.Errorf("engine seed = %d, want %d", engineSeed, seedOffset+tt.seed)
+			}
+
+			state := run(t, tt.args, "")[0].State
+			if got := int64(state["seed"].(float64)); got != tt.seed {
+				t.Errorf("reported seed = %d, want %d", got, tt.seed)
+			}
+		})
+	}
+}
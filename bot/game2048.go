@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"termiplay/go-backend/game"
+)
+
+// game2048 通过协议玩 2048
+type game2048 struct {
+	g *game.Game2048
+}
+
+// game2048State 是 2048 的局面
+type game2048State struct {
+	Game   string  `json:"game"`
+	Seed   int64   `json:"seed"`
+	Size   int     `json:"size"`
+	Target int     `json:"target"`
+	Board  [][]int `json:"board"`
+	Score  int     `json:"score"`
+	Won    bool    `json:"won"`
+	Over   bool    `json:"over"`
+}
+
+func newGame2048(seed int64) *game2048 {
+	return &game2048{g: game.NewGame2048(seedOffset + seed)}
+}
+
+func (b *game2048) State() any {
+	return game2048State{
+		Game:   "2048",
+		Seed:   b.g.Seed - seedOffset,
+		Size:   b.g.Size,
+		Target: b.g.Target,
+		Board:  game.CloneGrid(b.g.Grid),
+		Score:  b.g.Score,
+		Won:    b.g.Won,
+		Over:   b.g.GameOver,
+	}
+}
+
+func (b *game2048) Do(cmd string, args []string) ([]Event, error) {
+	if !slices.Contains(game.Directions, cmd) {
+		return nil, fmt.Errorf("未知的命令 %q，可用的有 left, right, up, down, quit", cmd)
+	}
+	if len(args) > 0 {
+		return nil, fmt.Errorf("%s 不接受参数", cmd)
+	}
+	if b.g.GameOver {
+		return nil, errors.New("游戏已经结束")
+	}
+
+	won := b.g.Won
+	r := b.g.Move(cmd)
+	if !r.Moved {
+		return nil, errors.New("这个方向无法移动")
+	}
+
+	var events []Event
+	for _, s := range r.Slides {
+		if s.From != s.To {
+			events = append(events, Event{Type: "slide", From: point(s.From), At: point(s.To), Value: s.Value})
+		}
+	}
+	for _, m := range r.Merges {
+		events = append(events, Event{Type: "merge", At: point(m.At), Value: m.Value})
+	}
+	if s := r.Spawn; s != nil {
+		events = append(events, Event{Type: "spawn", At: point(s.At), Value: s.Value})
+	}
+	if b.g.Won && !won {
+		events = append(events, Event{Type: "won", Value: b.g.Target})
+	}
+	if b.g.GameOver {
+		events = append(events, Event{Type: "over", Value: b.g.Score})
+	}
+	return events, nil
+}
+
+func point(p game.Point) *Point {
+	return &Point{X: p.X, Y: p.Y}
+}
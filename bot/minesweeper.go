@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"termiplay/go-backend/game"
+)
+
+// minesweeper 通过协议玩扫雷
+type minesweeper struct {
+	ms *game.Minesweeper
+}
+
+// minesweeperState 是扫雷的局面。Board 每行一个字符串，每个字符一个格子：
+// "#" 未翻开，"F" 旗子，"0" 到 "8" 已翻开的数字，游戏结束后 "*" 是雷，"X" 是踩到的雷
+type minesweeperState struct {
+	Game   string   `json:"game"`
+	Seed   int64    `json:"seed"`
+	Width  int      `json:"width"`
+	Height int      `json:"height"`
+	Mines  int      `json:"mines"`
+	Flags  int      `json:"flags"`
+	Board  []string `json:"board"`
+	Won    bool     `json:"won"`
+	Over   bool     `json:"over"`
+}
+
+func newMinesweeper(seed int64, flags []string) (*minesweeper, error) {
+	d := game.Easy
+	for _, f := range flags {
+		var ok bool
+		if d, ok = game.ParseDifficulty(strings.TrimPrefix(f, "--")); !ok || !strings.HasPrefix(f, "--") {
+			return nil, fmt.Errorf("未知的参数 %q", f)
+		}
+	}
+	return &minesweeper{ms: game.NewMinesweeper(d, seedOffset+seed)}, nil
+}
+
+func (b *minesweeper) State() any {
+	ms := b.ms
+	board := make([]string, ms.Height)
+	for y := range board {
+		var row strings.Builder
+		for x := 0; x < ms.Width; x++ {
+			row.WriteByte(b.cell(x, y))
+		}
+		board[y] = row.String()
+	}
+	return minesweeperState{
+		Game:   "minesweeper",
+		Seed:   ms.Seed - seedOffset,
+		Width:  ms.Width,
+		Height: ms.Height,
+		Mines:  ms.MineCount,
+		Flags:  ms.Flags,
+		Board:  board,
+		Won:    ms.Won,
+		Over:   ms.GameOver,
+	}
+}
+
+// cell 返回格子 (x, y) 在 Board 中的字符
+func (b *minesweeper) cell(x, y int) byte {
+	c := b.ms.Grid[y][x]
+	switch {
+	case b.exploded(x, y):
+		return 'X'
+	case c.State == game.CellFlagged:
+		return 'F'
+	case c.State == game.CellRevealed:
+		return '0' + byte(c.Adjacent)
+	case b.ms.GameOver && c.IsMine:
+		return '*'
+	}
+	return '#'
+}
+
+func (b *minesweeper) exploded(x, y int) bool {
+	for _, p := range b.ms.Exploded {
+		if p.X == x && p.Y == y {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *minesweeper) Do(cmd string, args []string) ([]Event, error) {
+	if cmd != "reveal" && cmd != "flag" && cmd != "chord" {
+		return nil, fmt.Errorf("未知的命令 %q，可用的有 reveal x y, flag x y, chord x y, quit", cmd)
+	}
+	x, y, err := coords(cmd, args)
+	if err != nil {
+		return nil, err
+	}
+	ms := b.ms
+	if x < 0 || x >= ms.Width || y < 0 || y >= ms.Height {
+		return nil, fmt.Errorf("坐标超出雷区，x 在 0 到 %d 之间，y 在 0 到 %d 之间", ms.Width-1, ms.Height-1)
+	}
+	if ms.GameOver {
+		return nil, errors.New("游戏已经结束")
+	}
+
+	before := b.State().(minesweeperState).Board
+	switch cmd {
+	case "reveal":
+		if !ms.Reveal(x, y) {
+			return nil, errors.New("这个格子已经翻开或者插了旗子")
+		}
+	case "flag":
+		if ms.Grid[y][x].State == game.CellRevealed {
+			return nil, errors.New("已经翻开的格子不能插旗")
+		}
+		ms.ToggleFlag(x, y)
+	case "chord":
+		if !ms.Chord(x, y) {
+			return nil, errors.New("只能在周围旗子数等于数字的格子上连开")
+		}
+	}
+	return b.events(before), nil
+}
+
+// events 对比操作前的 Board，列出发生变化的格子
+func (b *minesweeper) events(before []string) []Event {
+	var events []Event
+	for y := 0; y < b.ms.Height; y++ {
+		for x := 0; x < b.ms.Width; x++ {
+			was, now := before[y][x], b.cell(x, y)
+			if was == now {
+				continue
+			}
+			at := &Point{X: x, Y: y}
+			switch {
+			case now == 'X':
+				events = append(events, Event{Type: "explode", At: at})
+			case now == 'F':
+				events = append(events, Event{Type: "flag", At: at})
+			case was == 'F' && now == '#':
+				events = append(events, Event{Type: "unflag", At: at})
+			case now >= '0' && now <= '8':
+				events = append(events, Event{Type: "reveal", At: at, Value: int(now - '0')})
+			}
+		}
+	}
+	switch {
+	case b.ms.Won:
+		events = append(events, Event{Type: "won"})
+	case b.ms.GameOver:
+		events = append(events, Event{Type: "over"})
+	}
+	return events
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"termiplay/go-backend/models"
+	"termiplay/go-backend/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// commandModel returns the first screen for a session started with an SSH
+// command, such as "ssh host 2048" or "ssh host replay <id>". Without a
+// command the player lands in the lobby.
+func commandModel(s *registry.Session, args []string) (tea.Model, error) {
+	if len(args) == 0 {
+		return models.NewLobbyModel(s), nil
+	}
+
+	name, args := args[0], args[1:]
+	switch name {
+	case "leaderboard":
+		if len(args) > 0 {
+			return nil, errors.New("leaderboard 不接受参数")
+		}
+		return models.NewLeaderboardModel(s), nil
+	case "replay":
+		if len(args) != 1 {
+			return nil, errors.New("用法: replay <录像编号>")
+		}
+		r, ok, err := s.Store.Replay(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("读取录像失败: %w", err)
+		}
+		if !ok {
+			return nil, fmt.Errorf("没有编号为 %q 的录像", args[0])
+		}
+		if models.ReplayHidden(s, r) {
+			return nil, errors.New("这段每日挑战的录像要等你完成这一天的挑战，或者挑战结束之后才能观看")
+		}
+		return models.NewReplayModel(r, nil)
+	}
+
+	g, ok := registry.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("未知的命令 %q", name)
+	}
+	if len(args) == 0 {
+		return g.New(s), nil
+	}
+	ag, ok := g.(registry.ArgsGame)
+	if !ok {
+		return nil, fmt.Errorf("%s 不接受参数", name)
+	}
+	m, err := ag.NewWithArgs(s, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return m, nil
+}
+
+// isCommand reports whether name is a command that commandModel accepts.
+func isCommand(name string) bool {
+	if name == "leaderboard" || name == "replay" {
+		return true
+	}
+	_, ok := registry.Lookup(name)
+	return ok
+}
+
+// commandUsage lists the commands accepted by commandModel.
+func commandUsage() string {
+	rows := [][2]string{{"(无)", "进入大厅"}}
+	for _, g := range registry.All() {
+		usage := g.ID()
+		if ag, ok := g.(registry.ArgsGame); ok {
+			usage += " " + ag.Usage()
+		}
+		rows = append(rows, [2]string{usage, g.Name()})
+	}
+	rows = append(rows,
+		[2]string{"leaderboard", "排行榜"},
+		[2]string{"replay <录像编号>", "播放录像"},
+	)
+	for _, sc := range models.Scripts {
+		rows = append(rows, [2]string{sc.Name + " " + sc.Usage, sc.Description})
+	}
+
+	width := 0
+	for _, r := range rows {
+		width = max(width, lipgloss.Width(r[0]))
+	}
+	var b strings.Builder
+	b.WriteString("可用的命令:\n")
+	for _, r := range rows {
+		b.WriteString("  " + r[0] + strings.Repeat(" ", width-lipgloss.Width(r[0])+2) + r[1] + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,294 @@
+// Package config 读取服务器的运行配置。
+//
+// 每一项配置都可以写在 TOML 配置文件中，也可以通过环境变量或命令行参数设置，
+// 后者优先：默认值 < 配置文件 < 环境变量 < 命令行参数。配置文件中的键名
+// 同时决定了另外两种写法，例如 data_dir 对应环境变量 TERMIPLAY_DATA_DIR
+// 和命令行参数 -data-dir。配置文件的路径由 -config 或 TERMIPLAY_CONFIG 指定，
+// 都没有指定时读取当前目录下的 termiplay.toml（如果存在）。
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"termiplay/go-backend/game"
+	"termiplay/go-backend/registry"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/log"
+)
+
+// DefaultFile 是没有指定配置文件时读取的文件
+const DefaultFile = "termiplay.toml"
+
+// envPrefix 是环境变量名的前缀
+const envPrefix = "TERMIPLAY_"
+
+// Configurable 由有配置项的游戏实现，例如扫雷默认选中的难度
+type Configurable interface {
+	registry.Game
+	// Configure 返回按 c 调整过的游戏
+	Configure(c Config) registry.Game
+}
+
+// Config 是服务器的运行配置
+type Config struct {
+	Host        string `toml:"host"`
+	Port        int    `toml:"port"`
+	HostKeyPath string `toml:"host_key_path"`
+	DataDir     string `toml:"data_dir"`
+
+	// Games 是大厅中列出的游戏的 ID，按这个顺序排列，默认是所有注册过的游戏
+	Games []string `toml:"games"`
+
+	// 扫雷难度选择界面默认选中的难度，以及 2048 设置界面默认的棋盘
+	MinesweeperDifficulty string `toml:"minesweeper_difficulty"`
+	Game2048Size          int    `toml:"game2048_size"`
+	Game2048Target        int    `toml:"game2048_target"`
+
+	// IdleTimeout 是没有任何输入多久之后断开连接，为 0 时不断开
+	IdleTimeout time.Duration `toml:"idle_timeout"`
+	// MaxSessions 是同时在线的连接数上限，为 0 时不限制
+	MaxSessions int    `toml:"max_sessions"`
+	LogLevel    string `toml:"log_level"`
+}
+
+// Default 返回没有任何配置时使用的值
+func Default() Config {
+	return Config{
+		Host:                  "0.0.0.0",
+		Port:                  23234,
+		HostKeyPath:           ".ssh/termiplay_ed25519",
+		DataDir:               "data",
+		Games:                 registry.IDs(),
+		MinesweeperDifficulty: game.Easy.String(),
+		Game2048Size:          game.Default2048Config.Size,
+		Game2048Target:        game.Default2048Config.Target,
+		IdleTimeout:           0,
+		MaxSessions:           0,
+		LogLevel:              "info",
+	}
+}
+
+// Address 返回监听地址
+func (c Config) Address() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// Difficulty 返回扫雷默认选中的难度，只应在 Validate 通过之后调用
+func (c Config) Difficulty() game.Difficulty {
+	d, _ := game.ParseDifficulty(c.MinesweeperDifficulty)
+	return d
+}
+
+// Game2048 返回 2048 默认的棋盘设置
+func (c Config) Game2048() game.Game2048Config {
+	return game.Game2048Config{Size: c.Game2048Size, Target: c.Game2048Target}
+}
+
+// Configure 把配置交给实现了 Configurable 的游戏，用作 registry.Enable 的参数
+func (c Config) Configure(g registry.Game) registry.Game {
+	if cg, ok := g.(Configurable); ok {
+		return cg.Configure(c)
+	}
+	return g
+}
+
+// Level 返回日志级别，只应在 Validate 通过之后调用
+func (c Config) Level() log.Level {
+	l, _ := log.ParseLevel(c.LogLevel)
+	return l
+}
+
+// Validate 检查所有配置项，返回所有问题而不只是第一个
+func (c Config) Validate() error {
+	var errs []error
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port: 端口必须在 1 到 65535 之间，而不是 %d", c.Port))
+	}
+	if c.HostKeyPath == "" {
+		errs = append(errs, errors.New("host_key_path: 不能为空"))
+	}
+	if c.DataDir == "" {
+		errs = append(errs, errors.New("data_dir: 不能为空"))
+	}
+	if len(c.Games) == 0 {
+		errs = append(errs, errors.New("games: 至少要启用一个游戏"))
+	}
+	available := registry.IDs()
+	for i, id := range c.Games {
+		switch {
+		case !slices.Contains(available, id):
+			errs = append(errs, fmt.Errorf("games: 未知的游戏 %q，可选的有 %s", id, strings.Join(available, ", ")))
+		case slices.Index(c.Games, id) != i:
+			errs = append(errs, fmt.Errorf("games: %q 重复", id))
+		}
+	}
+	if _, ok := game.ParseDifficulty(c.MinesweeperDifficulty); !ok {
+		errs = append(errs, fmt.Errorf("minesweeper_difficulty: 未知的难度 %q，可选的有 easy, medium, hard", c.MinesweeperDifficulty))
+	}
+	if err := c.Game2048().Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("game2048_size, game2048_target: %w", err))
+	} else if !slices.Contains(game.Game2048Targets, c.Game2048Target) {
+		errs = append(errs, fmt.Errorf("game2048_target: 目标必须是 %d 到 %d 之间的 2 的幂",
+			game.Game2048Targets[0], game.Game2048Targets[len(game.Game2048Targets)-1]))
+	}
+	if c.IdleTimeout < 0 {
+		errs = append(errs, errors.New("idle_timeout: 不能为负数"))
+	}
+	if c.MaxSessions < 0 {
+		errs = append(errs, errors.New("max_sessions: 不能为负数"))
+	}
+	if _, err := log.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("log_level: 未知的日志级别 %q，可选的有 debug, info, warn, error, fatal", c.LogLevel))
+	}
+	return errors.Join(errs...)
+}
+
+// option 描述一个配置项，name 是它在配置文件中的键名
+type option struct {
+	name  string
+	usage string
+	set   func(c *Config, v string) error
+}
+
+var options = []option{
+	{"host", "监听的地址", func(c *Config, v string) error {
+		c.Host = v
+		return nil
+	}},
+	{"port", "监听的端口", func(c *Config, v string) error {
+		return parseInt(&c.Port, v)
+	}},
+	{"host_key_path", "SSH 主机密钥的路径，不存在时自动生成", func(c *Config, v string) error {
+		c.HostKeyPath = v
+		return nil
+	}},
+	{"data_dir", "保存玩家、存档和成绩的目录", func(c *Config, v string) error {
+		c.DataDir = v
+		return nil
+	}},
+	{"games", "启用的游戏，用逗号分隔，例如 minesweeper,2048", func(c *Config, v string) error {
+		c.Games = nil
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				c.Games = append(c.Games, id)
+			}
+		}
+		return nil
+	}},
+	{"minesweeper_difficulty", "扫雷默认选中的难度：easy, medium 或 hard", func(c *Config, v string) error {
+		c.MinesweeperDifficulty = v
+		return nil
+	}},
+	{"game2048_size", "2048 默认的棋盘边长", func(c *Config, v string) error {
+		return parseInt(&c.Game2048Size, v)
+	}},
+	{"game2048_target", "2048 默认的目标方块", func(c *Config, v string) error {
+		return parseInt(&c.Game2048Target, v)
+	}},
+	{"idle_timeout", "没有输入多久之后断开连接，例如 15m，0 表示不断开", func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return errors.New("不是有效的时长，例如 30s 或 15m")
+		}
+		c.IdleTimeout = d
+		return nil
+	}},
+	{"max_sessions", "同时在线的连接数上限，0 表示不限制", func(c *Config, v string) error {
+		return parseInt(&c.MaxSessions, v)
+	}},
+	{"log_level", "日志级别：debug, info, warn, error 或 fatal", func(c *Config, v string) error {
+		c.LogLevel = v
+		return nil
+	}},
+}
+
+func parseInt(dst *int, v string) error {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("%q 不是整数", v)
+	}
+	*dst = n
+	return nil
+}
+
+// Load 依次读取配置文件、环境变量和命令行参数 args，并检查结果。
+// args 中包含 -h 时返回 flag.ErrHelp。
+func Load(args []string) (Config, error) {
+	fs := flag.NewFlagSet("termiplay", flag.ContinueOnError)
+	path := fs.String("config", os.Getenv(envPrefix+"CONFIG"), "配置文件的路径，环境变量 "+envPrefix+"CONFIG")
+
+	// 命令行参数最后才生效，先记下来
+	type setting struct {
+		option
+		value string
+	}
+	var flags []setting
+	for _, o := range options {
+		fs.Func(flagName(o.name), o.usage+"，环境变量 "+envName(o.name), func(v string) error {
+			flags = append(flags, setting{o, v})
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("多余的参数 %q", fs.Args())
+	}
+
+	c := Default()
+	if err := c.readFile(*path); err != nil {
+		return Config{}, err
+	}
+
+	for _, o := range options {
+		if v, ok := os.LookupEnv(envName(o.name)); ok {
+			if err := o.set(&c, v); err != nil {
+				return Config{}, fmt.Errorf("%s: %w", envName(o.name), err)
+			}
+		}
+	}
+	for _, f := range flags {
+		if err := f.set(&c, f.value); err != nil {
+			return Config{}, fmt.Errorf("-%s: %w", flagName(f.name), err)
+		}
+	}
+
+	return c, c.Validate()
+}
+
+// readFile 读取配置文件 path，path 为空时读取存在的 DefaultFile
+func (c *Config) readFile(path string) error {
+	if path == "" {
+		if _, err := os.Stat(DefaultFile); err != nil {
+			return nil
+		}
+		path = DefaultFile
+	}
+
+	md, err := toml.DecodeFile(path, c)
+	if err != nil {
+		return fmt.Errorf("读取配置文件 %s: %w", path, err)
+	}
+	if keys := md.Undecoded(); len(keys) > 0 {
+		return fmt.Errorf("配置文件 %s: 未知的配置项 %v", path, keys)
+	}
+	return nil
+}
+
+func flagName(name string) string {
+	return strings.ReplaceAll(name, "_", "-")
+}
+
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(name)
+}
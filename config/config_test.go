@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"termiplay/go-backend/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stubGame 代替真正的游戏注册到 registry，config 不能导入 models
+type stubGame string
+
+func (g stubGame) ID() string                    { return string(g) }
+func (g stubGame) Name() string                  { return string(g) }
+func (stubGame) Description() string             { return "" }
+func (stubGame) New(*registry.Session) tea.Model { return nil }
+
+func init() {
+	registry.Register(stubGame("minesweeper"), 10)
+	registry.Register(stubGame("2048"), 20)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "termiplay.toml")
+	file := "port = 1000\ndata_dir = \"file\"\nlog_level = \"debug\"\ngames = [\"2048\"]\n"
+	if err := os.WriteFile(path, []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envPrefix+"CONFIG", path)
+	t.Setenv(envPrefix+"PORT", "2000")
+	t.Setenv(envPrefix+"DATA_DIR", "env")
+
+	c, err := Load([]string{"-port", "3000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		got, want any
+	}{
+		{"命令行参数优先于环境变量", c.Port, 3000},
+		{"环境变量优先于配置文件", c.DataDir, "env"},
+		{"配置文件优先于默认值", c.LogLevel, "debug"},
+		{"配置文件中的列表", strings.Join(c.Games, ","), "2048"},
+		{"没有设置时使用默认值", c.Host, Default().Host},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	t.Setenv(envPrefix+"CONFIG", "")
+	c, err := Load(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(c.Games, ","); got != "minesweeper,2048" {
+		t.Errorf("default games = %s, want minesweeper,2048", got)
+	}
+}
+
+func TestValidateCollectsAllErrors(t *testing.T) {
+	c := Default()
+	c.Port = 0
+	c.DataDir = ""
+	c.Games = []string{"minesweeper", "tetris", "minesweeper"}
+	c.MinesweeperDifficulty = "insane"
+	c.LogLevel = "loud"
+
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted an invalid config")
+	}
+	for _, want := range []string{"port:", "data_dir:", `"tetris"`, `"minesweeper" 重复`, "minesweeper_difficulty:", "log_level:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate error does not mention %s:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadRejectsUnknownGames(t *testing.T) {
+	t.Setenv(envPrefix+"CONFIG", "")
+	_, err := Load([]string{"-games", "minesweeper, tetris"})
+	if err == nil || !strings.Contains(err.Error(), `未知的游戏 "tetris"`) {
+		t.Errorf("Load with an unknown game returned %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package game
+
+import (
+	"time"
+)
+
+// ActionKind 是玩家操作的类型。
+type ActionKind string
+
+const (
+	ActionMove     ActionKind = "move"     // 2048 中向 Dir 方向移动
+	ActionUndo     ActionKind = "undo"     // 2048 中撤销上一步移动
+	ActionContinue ActionKind = "continue" // 2048 中拼出目标后选择继续
+	ActionFinish   ActionKind = "finish"   // 2048 中拼出目标后选择结束
+	ActionReveal   ActionKind = "reveal"   // 扫雷中翻开 (X, Y)
+	ActionFlag     ActionKind = "flag"     // 扫雷中切换 (X, Y) 的标记
+	ActionChord    ActionKind = "chord"    // 扫雷中翻开数字 (X, Y) 周围未标记的格子
+)
+
+// Action 记录玩家的一次操作，种子加上操作序列就可以完整重现一局游戏。
+type Action struct {
+	Kind ActionKind    `json:"k"`
+	Dir  string        `json:"d,omitempty"`
+	X    int           `json:"x,omitempty"`
+	Y    int           `json:"y,omitempty"`
+	At   time.Duration `json:"t"` // 距离开局的时间
+}
@@ -0,0 +1,18 @@
+package game
+
+import (
+	"hash/fnv"
+	"time"
+)
+
+// DailyKey 返回 t 所在日期的每日挑战标识，例如 "2024-05-01"。
+func DailyKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// DailySeed 返回每日挑战 day 的种子，同一天所有玩家得到相同的种子。
+func DailySeed(day string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("termiplay-daily-" + day))
+	return int64(h.Sum64() % 1_000_000_000)
+}
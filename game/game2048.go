@@ -1,248 +1,383 @@
 package game
 
 import (
-	"math/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
 	"time"
 )
 
-var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+// 棋盘边长的范围
+const (
+	Min2048Size = 3
+	Max2048Size = 8
+)
+
+// Game2048Config 描述棋盘的边长和获胜需要拼出的方块。
+type Game2048Config struct {
+	Size   int
+	Target int
+}
+
+// Default2048Config 是经典的 4x4 棋盘，目标 2048。
+var Default2048Config = Game2048Config{Size: 4, Target: 2048}
+
+// Game2048Targets 是玩家开局时可以选择的目标方块。
+var Game2048Targets = []int{64, 128, 256, 512, 1024, 2048, 4096, 8192, 16384, 32768, 65536}
+
+func (c Game2048Config) String() string {
+	return fmt.Sprintf("%dx%d-%d", c.Size, c.Size, c.Target)
+}
+
+// Validate 检查设置是否可用。目标必须是 2 的幂，并且在这个大小的棋盘上
+// 理论上拼得出来：N×N 的棋盘最大能拼出 2^(N×N+1)。
+func (c Game2048Config) Validate() error {
+	switch {
+	case c.Size < Min2048Size || c.Size > Max2048Size:
+		return fmt.Errorf("边长必须在 %d 到 %d 之间", Min2048Size, Max2048Size)
+	case c.Target < 8 || c.Target&(c.Target-1) != 0:
+		return errors.New("目标必须是不小于 8 的 2 的幂")
+	case c.Size*c.Size+1 < 31 && c.Target > 1<<(c.Size*c.Size+1):
+		return fmt.Errorf("%d×%d 的棋盘拼不出 %d", c.Size, c.Size, c.Target)
+	}
+	return nil
+}
 
 type Game2048 struct {
-	Grid     [4][4]int
-	Score    int
-	GameOver bool
-	Won      bool
+	Grid      [][]int
+	Size      int // 棋盘边长
+	Target    int // 拼出这个方块即获胜
+	Score     int
+	GameOver  bool
+	Won       bool
+	Seed      int64 // 决定新方块出现位置和数值的种子
+	StartTime time.Time
+	Actions   []Action // 所有改变了棋盘的移动和撤销，用于回放
+
+	// History 保存最近 HistoryLimit 步移动之前的局面，用于撤销，
+	// HistoryLimit 为 0 时不能撤销
+	History      []Game2048Snapshot
+	HistoryLimit int
+	Undos        int // 撤销的次数
+
+	// Continued 为 true 表示玩家拼出目标后选择了继续游戏
+	Continued bool
+
+	rng *rand.Rand
+	src *rand.PCG
+}
+
+// NewGame2048 创建一局经典的 4x4 游戏，相同的 seed 和相同的操作会得到相同的棋局。
+func NewGame2048(seed int64) *Game2048 {
+	return NewCustomGame2048(Default2048Config, seed)
 }
 
-func NewGame2048() *Game2048 {
+// NewCustomGame2048 按 cfg 创建一局新游戏，调用方应先用 Validate 检查 cfg。
+func NewCustomGame2048(cfg Game2048Config, seed int64) *Game2048 {
 	g := &Game2048{
-		Score:    0,
-		GameOver: false,
-		Won:      false,
+		Grid:      newGrid(cfg.Size),
+		Size:      cfg.Size,
+		Target:    cfg.Target,
+		Score:     0,
+		GameOver:  false,
+		Won:       false,
+		Seed:      seed,
+		StartTime: time.Now(),
 	}
+	g.rng, g.src = newRand(seed)
 	g.addRandomTile()
 	g.addRandomTile()
 	return g
 }
 
-func (g *Game2048) addRandomTile() {
-	var empty []struct{ x, y int }
-	for y := 0; y < 4; y++ {
-		for x := 0; x < 4; x++ {
+// newGrid 返回 size×size 的空棋盘
+func newGrid(size int) [][]int {
+	grid := make([][]int, size)
+	for y := range grid {
+		grid[y] = make([]int, size)
+	}
+	return grid
+}
+
+// CloneGrid 返回棋盘的副本。
+func CloneGrid(grid [][]int) [][]int {
+	c := make([][]int, len(grid))
+	for y, row := range grid {
+		c[y] = append([]int(nil), row...)
+	}
+	return c
+}
+
+// Config 返回这局游戏的设置。
+func (g *Game2048) Config() Game2048Config {
+	return Game2048Config{Size: g.Size, Target: g.Target}
+}
+
+// addRandomTile 在随机的空格上放一个 2 或 4，棋盘已满时返回 nil
+func (g *Game2048) addRandomTile() *TileSpawn {
+	var empty []Point
+	for y := 0; y < g.Size; y++ {
+		for x := 0; x < g.Size; x++ {
 			if g.Grid[y][x] == 0 {
-				empty = append(empty, struct{ x, y int }{x, y})
+				empty = append(empty, Point{X: x, Y: y})
 			}
 		}
 	}
 
 	if len(empty) == 0 {
-		return
+		return nil
 	}
 
-	pos := empty[rng.Intn(len(empty))]
-	if rng.Float32() < 0.9 {
-		g.Grid[pos.y][pos.x] = 2
-	} else {
-		g.Grid[pos.y][pos.x] = 4
+	spawn := &TileSpawn{At: empty[g.rng.IntN(len(empty))], Value: 4}
+	if g.rng.Float32() < 0.9 {
+		spawn.Value = 2
 	}
+	g.Grid[spawn.At.Y][spawn.At.X] = spawn.Value
+	return spawn
 }
 
-func (g *Game2048) Move(direction string) bool {
-	changed := false
+// MaxUndoHistory 是最多可以连续撤销的步数。
+const MaxUndoHistory = 100
 
-	switch direction {
-	case "up":
-		changed = g.moveUp()
-	case "down":
-		changed = g.moveDown()
-	case "left":
-		changed = g.moveLeft()
-	case "right":
-		changed = g.moveRight()
-	}
+// Game2048Snapshot 是一步移动之前的局面，包括随机数生成器的状态，
+// 撤销后再走同样的一步会出现同样的新方块。
+type Game2048Snapshot struct {
+	Grid  [][]int
+	Score int
+	Won   bool
+	RNG   []byte
+}
 
-	if changed {
-		g.addRandomTile()
-		g.checkGameState()
-	}
+// TileSlide 描述一次移动中一个方块的去向，没有移动的方块 From 等于 To。
+type TileSlide struct {
+	From, To Point
+	Value    int // 移动前的数值
+}
 
-	return changed
+// TileMerge 描述两个方块在 At 合并成了 Value。
+type TileMerge struct {
+	At    Point
+	Value int
 }
 
-func (g *Game2048) moveLeft() bool {
-	changed := false
-	for y := 0; y < 4; y++ {
-		row := make([]int, 0, 4)
-		for x := 0; x < 4; x++ {
-			if g.Grid[y][x] != 0 {
-				row = append(row, g.Grid[y][x])
-			}
-		}
+// TileSpawn 描述移动后在 At 出现的新方块。
+type TileSpawn struct {
+	At    Point
+	Value int
+}
 
-		merged := make([]int, 0, 4)
-		for i := 0; i < len(row); i++ {
-			if i < len(row)-1 && row[i] == row[i+1] {
-				merged = append(merged, row[i]*2)
-				g.Score += row[i] * 2
-				if row[i]*2 == 2048 && !g.Won {
-					g.Won = true
-				}
-				i++
-				changed = true
-			} else {
-				merged = append(merged, row[i])
+// MoveResult 是一次移动的结果，Score 等于所有合并的数值之和。
+type MoveResult struct {
+	Moved  bool // 棋盘是否发生了变化，没有变化时不会出现新方块
+	Slides []TileSlide
+	Merges []TileMerge
+	Spawn  *TileSpawn
+	Score  int // 本次移动得到的分数
+}
+
+// Move 把所有方块推向 direction（up/down/left/right），棋盘发生变化时
+// 记录操作并生成一个新方块。
+func (g *Game2048) Move(direction string) MoveResult {
+	var before Game2048Snapshot
+	if g.HistoryLimit > 0 {
+		before = g.snapshot()
+	}
+
+	var r MoveResult
+	switch direction {
+	case "up", "down", "left", "right":
+		r = g.slide(direction)
+	}
+
+	if r.Moved {
+		g.Score += r.Score
+		for _, m := range r.Merges {
+			if m.Value == g.Target {
+				g.Won = true
 			}
 		}
 
-		for x := 0; x < 4; x++ {
-			if x < len(merged) {
-				if g.Grid[y][x] != merged[x] {
-					changed = true
-				}
-				g.Grid[y][x] = merged[x]
-			} else {
-				if g.Grid[y][x] != 0 {
-					changed = true
-				}
-				g.Grid[y][x] = 0
+		if g.HistoryLimit > 0 {
+			g.History = append(g.History, before)
+			if len(g.History) > g.HistoryLimit {
+				g.History = g.History[len(g.History)-g.HistoryLimit:]
 			}
 		}
+		g.Actions = append(g.Actions, Action{Kind: ActionMove, Dir: direction, At: time.Since(g.StartTime)})
+		r.Spawn = g.addRandomTile()
+		g.checkGameState()
 	}
-	return changed
+
+	return r
 }
 
-func (g *Game2048) moveRight() bool {
-	changed := false
-	for y := 0; y < 4; y++ {
-		row := make([]int, 0, 4)
-		for x := 3; x >= 0; x-- {
-			if g.Grid[y][x] != 0 {
-				row = append(row, g.Grid[y][x])
-			}
-		}
+// snapshot 返回当前的局面
+func (g *Game2048) snapshot() Game2048Snapshot {
+	state, _ := g.src.MarshalBinary()
+	return Game2048Snapshot{Grid: CloneGrid(g.Grid), Score: g.Score, Won: g.Won, RNG: state}
+}
 
-		merged := make([]int, 0, 4)
-		for i := 0; i < len(row); i++ {
-			if i < len(row)-1 && row[i] == row[i+1] {
-				merged = append(merged, row[i]*2)
-				g.Score += row[i] * 2
-				if row[i]*2 == 2048 && !g.Won {
-					g.Won = true
-				}
-				i++
-				changed = true
-			} else {
-				merged = append(merged, row[i])
-			}
-		}
+// Deciding 报告是否拼出了目标，正等玩家选择继续还是结束。
+func (g *Game2048) Deciding() bool {
+	return g.Won && !g.Continued && !g.GameOver
+}
 
-		for x := 3; x >= 0; x-- {
-			idx := 3 - x
-			if idx < len(merged) {
-				if g.Grid[y][x] != merged[idx] {
-					changed = true
-				}
-				g.Grid[y][x] = merged[idx]
-			} else {
-				if g.Grid[y][x] != 0 {
-					changed = true
-				}
-				g.Grid[y][x] = 0
-			}
-		}
+// Continue 在拼出目标后选择继续游戏，不在等待选择时什么也不做。
+func (g *Game2048) Continue() bool {
+	if !g.Deciding() {
+		return false
 	}
-	return changed
+	g.Continued = true
+	g.Actions = append(g.Actions, Action{Kind: ActionContinue, At: time.Since(g.StartTime)})
+	return true
 }
 
-func (g *Game2048) moveUp() bool {
-	changed := false
-	for x := 0; x < 4; x++ {
-		col := make([]int, 0, 4)
-		for y := 0; y < 4; y++ {
-			if g.Grid[y][x] != 0 {
-				col = append(col, g.Grid[y][x])
-			}
-		}
+// Finish 在拼出目标后结束游戏，提交当前的成绩。
+func (g *Game2048) Finish() bool {
+	if !g.Won || g.GameOver {
+		return false
+	}
+	g.GameOver = true
+	g.Actions = append(g.Actions, Action{Kind: ActionFinish, At: time.Since(g.StartTime)})
+	return true
+}
 
-		merged := make([]int, 0, 4)
-		for i := 0; i < len(col); i++ {
-			if i < len(col)-1 && col[i] == col[i+1] {
-				merged = append(merged, col[i]*2)
-				g.Score += col[i] * 2
-				if col[i]*2 == 2048 && !g.Won {
-					g.Won = true
-				}
-				i++
-				changed = true
-			} else {
-				merged = append(merged, col[i])
-			}
-		}
+// CanUndo 报告是否还有可以撤销的移动。无路可走时可以撤销导致无路可走的那一步，
+// 拼出目标后选择结束的对局不能撤销。
+func (g *Game2048) CanUndo() bool {
+	if len(g.History) == 0 {
+		return false
+	}
+	return !g.GameOver || g.Actions[len(g.Actions)-1].Kind != ActionFinish
+}
 
-		for y := 0; y < 4; y++ {
-			if y < len(merged) {
-				if g.Grid[y][x] != merged[y] {
-					changed = true
-				}
-				g.Grid[y][x] = merged[y]
-			} else {
-				if g.Grid[y][x] != 0 {
-					changed = true
-				}
-				g.Grid[y][x] = 0
-			}
+// Undo 撤销上一步移动，恢复棋盘、分数和随机数生成器，无路可走时撤销会让游戏继续。
+func (g *Game2048) Undo() bool {
+	if !g.CanUndo() {
+		return false
+	}
+	last := g.History[len(g.History)-1]
+	if err := g.src.UnmarshalBinary(last.RNG); err != nil {
+		return false
+	}
+	g.History = g.History[:len(g.History)-1]
+	g.Grid = last.Grid
+	g.Score = last.Score
+	g.Won = last.Won
+	g.GameOver = false
+	g.Undos++
+	g.Actions = append(g.Actions, Action{Kind: ActionUndo, At: time.Since(g.StartTime)})
+	return true
+}
+
+// Apply 执行一次记录下来的操作，用于回放。
+func (g *Game2048) Apply(a Action) bool {
+	switch a.Kind {
+	case ActionMove:
+		return g.Move(a.Dir).Moved
+	case ActionUndo:
+		return g.Undo()
+	case ActionContinue:
+		return g.Continue()
+	case ActionFinish:
+		return g.Finish()
+	}
+	return false
+}
+
+// lineSlide 描述一条线压缩时一个方块的去向，from 和 to 是线内的位置，
+// 位置 0 是方块滑向的一端
+type lineSlide struct {
+	from, to int
+}
+
+// slideLine 把一条线上的方块推向位置 0，相邻的相同方块合并一次。
+// 返回压缩后的线、每个方块的去向以及发生合并的位置，不修改 line。
+func slideLine(line []int) (out []int, slides []lineSlide, merges []int) {
+	out = make([]int, len(line))
+	to := -1
+	open := false // out[to] 是否还能和下一个方块合并
+	for from, v := range line {
+		if v == 0 {
+			continue
+		}
+		if open && out[to] == v {
+			out[to] *= 2
+			open = false
+			merges = append(merges, to)
+		} else {
+			to++
+			out[to] = v
+			open = true
 		}
+		slides = append(slides, lineSlide{from: from, to: to})
 	}
-	return changed
+	return out, slides, merges
 }
 
-func (g *Game2048) moveDown() bool {
-	changed := false
-	for x := 0; x < 4; x++ {
-		col := make([]int, 0, 4)
-		for y := 3; y >= 0; y-- {
-			if g.Grid[y][x] != 0 {
-				col = append(col, g.Grid[y][x])
-			}
+// Directions 是 2048 中可以移动的方向。
+var Directions = []string{"up", "down", "left", "right"}
+
+// Slide 返回把 grid 推向 direction 之后的棋盘和移动的情况，不生成新方块，
+// 也不修改 grid。用于在不影响对局的情况下推演局面。
+func Slide(grid [][]int, direction string) ([][]int, MoveResult) {
+	g := &Game2048{Grid: CloneGrid(grid), Size: len(grid)}
+	return g.Grid, g.slide(direction)
+}
+
+// line 返回 direction 方向上第 i 条线经过的格子，第一个格子是方块滑向的一端
+func (g *Game2048) line(direction string, i int) []Point {
+	cells := make([]Point, g.Size)
+	for k := range cells {
+		switch direction {
+		case "left":
+			cells[k] = Point{X: k, Y: i}
+		case "right":
+			cells[k] = Point{X: g.Size - 1 - k, Y: i}
+		case "up":
+			cells[k] = Point{X: i, Y: k}
+		case "down":
+			cells[k] = Point{X: i, Y: g.Size - 1 - k}
 		}
+	}
+	return cells
+}
 
-		merged := make([]int, 0, 4)
-		for i := 0; i < len(col); i++ {
-			if i < len(col)-1 && col[i] == col[i+1] {
-				merged = append(merged, col[i]*2)
-				g.Score += col[i] * 2
-				if col[i]*2 == 2048 && !g.Won {
-					g.Won = true
-				}
-				i++
-				changed = true
-			} else {
-				merged = append(merged, col[i])
-			}
+// slide 把所有方块推向 direction，返回移动和合并的情况，不生成新方块
+func (g *Game2048) slide(direction string) MoveResult {
+	var r MoveResult
+	for i := 0; i < g.Size; i++ {
+		cells := g.line(direction, i)
+		line := make([]int, len(cells))
+		for k, c := range cells {
+			line[k] = g.Grid[c.Y][c.X]
 		}
 
-		for y := 3; y >= 0; y-- {
-			idx := 3 - y
-			if idx < len(merged) {
-				if g.Grid[y][x] != merged[idx] {
-					changed = true
-				}
-				g.Grid[y][x] = merged[idx]
-			} else {
-				if g.Grid[y][x] != 0 {
-					changed = true
-				}
-				g.Grid[y][x] = 0
+		out, slides, merges := slideLine(line)
+		for _, sl := range slides {
+			from, to := cells[sl.from], cells[sl.to]
+			r.Slides = append(r.Slides, TileSlide{From: from, To: to, Value: line[sl.from]})
+			// 合并的两个方块中至少有一个离开了原来的位置
+			if from != to {
+				r.Moved = true
 			}
 		}
+		for _, k := range merges {
+			r.Merges = append(r.Merges, TileMerge{At: cells[k], Value: out[k]})
+			r.Score += out[k]
+		}
+		for k, c := range cells {
+			g.Grid[c.Y][c.X] = out[k]
+		}
 	}
-	return changed
+	return r
 }
 
 func (g *Game2048) checkGameState() {
 	// 检查是否还有空格
-	for y := 0; y < 4; y++ {
-		for x := 0; x < 4; x++ {
+	for y := 0; y < g.Size; y++ {
+		for x := 0; x < g.Size; x++ {
 			if g.Grid[y][x] == 0 {
 				return
 			}
@@ -250,13 +385,13 @@ func (g *Game2048) checkGameState() {
 	}
 
 	// 检查是否可以合并
-	for y := 0; y < 4; y++ {
-		for x := 0; x < 4; x++ {
+	for y := 0; y < g.Size; y++ {
+		for x := 0; x < g.Size; x++ {
 			current := g.Grid[y][x]
-			if y < 3 && g.Grid[y+1][x] == current {
+			if y < g.Size-1 && g.Grid[y+1][x] == current {
 				return
 			}
-			if x < 3 && g.Grid[y][x+1] == current {
+			if x < g.Size-1 && g.Grid[y][x+1] == current {
 				return
 			}
 		}
@@ -265,11 +400,46 @@ func (g *Game2048) checkGameState() {
 	g.GameOver = true
 }
 
-func (g *Game2048) Reset() {
-	g.Grid = [4][4]int{}
+// Reset 使用新的种子重新开始。
+func (g *Game2048) Reset(seed int64) {
+	g.Seed = seed
+	g.rng, g.src = newRand(seed)
+	g.StartTime = time.Now()
+	g.Actions = nil
+	g.History = nil
+	g.Undos = 0
+	g.Continued = false
+	g.Grid = newGrid(g.Size)
 	g.Score = 0
 	g.GameOver = false
 	g.Won = false
 	g.addRandomTile()
 	g.addRandomTile()
 }
+
+type game2048Fields Game2048
+
+// MarshalJSON 连同随机数生成器的状态一起保存，恢复后新方块的出现顺序不变。
+func (g *Game2048) MarshalJSON() ([]byte, error) {
+	state, err := g.src.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		*game2048Fields
+		RNG []byte
+	}{(*game2048Fields)(g), state})
+}
+
+func (g *Game2048) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*game2048Fields
+		RNG []byte
+	}{game2048Fields: (*game2048Fields)(g)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	g.rng, g.src, err = restoreRand(aux.RNG)
+	return err
+}
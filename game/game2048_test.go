@@ -0,0 +1,271 @@
+package game
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSlideLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   []int
+		want   []int
+		merges []int
+	}{
+		{"四个相同", []int{2, 2, 2, 2}, []int{4, 4, 0, 0}, []int{0, 1}},
+		{"合并后不再合并", []int{2, 2, 4, 0}, []int{4, 4, 0, 0}, []int{0}},
+		{"不连锁合并", []int{4, 4, 8, 0}, []int{8, 8, 0, 0}, []int{0}},
+		{"中间有空格", []int{2, 0, 0, 2}, []int{4, 0, 0, 0}, []int{0}},
+		{"三个相同", []int{0, 2, 2, 2}, []int{4, 2, 0, 0}, []int{0}},
+		{"空线", []int{0, 0, 0, 0}, []int{0, 0, 0, 0}, nil},
+		{"满而不能合并", []int{2, 4, 8, 16}, []int{2, 4, 8, 16}, nil},
+		{"满且两两合并", []int{8, 8, 16, 16}, []int{16, 32, 0, 0}, []int{0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := slices.Clone(tt.line)
+			out, slides, merges := slideLine(line)
+			if !slices.Equal(out, tt.want) {
+				t.Errorf("slideLine(%v) = %v, want %v", tt.line, out, tt.want)
+			}
+			if !slices.Equal(merges, tt.merges) {
+				t.Errorf("slideLine(%v) merges = %v, want %v", tt.line, merges, tt.merges)
+			}
+			if !slices.Equal(line, tt.line) {
+				t.Errorf("slideLine modified its input: %v", line)
+			}
+			tiles := 0
+			for _, v := range tt.line {
+				if v != 0 {
+					tiles++
+				}
+			}
+			if len(slides) != tiles {
+				t.Errorf("slideLine(%v) reported %d slides for %d tiles", tt.line, len(slides), tiles)
+			}
+		})
+	}
+}
+
+func TestMove(t *testing.T) {
+	// 这个棋盘沿任何方向都不对称，每个方向的结果都不同
+	board := [][]int{
+		{2, 0, 2, 4},
+		{0, 4, 4, 4},
+		{2, 0, 0, 0},
+		{0, 0, 2, 2},
+	}
+	tests := []struct {
+		dir   string
+		want  [][]int
+		score int
+	}{
+		{"left", [][]int{{4, 4, 0, 0}, {8, 4, 0, 0}, {2, 0, 0, 0}, {4, 0, 0, 0}}, 16},
+		{"right", [][]int{{0, 0, 4, 4}, {0, 0, 4, 8}, {0, 0, 0, 2}, {0, 0, 0, 4}}, 16},
+		{"up", [][]int{{4, 4, 2, 8}, {0, 0, 4, 2}, {0, 0, 2, 0}, {0, 0, 0, 0}}, 12},
+		{"down", [][]int{{0, 0, 0, 0}, {0, 0, 2, 0}, {0, 0, 4, 8}, {4, 4, 2, 2}}, 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			g := NewGame2048(1)
+			g.Grid = CloneGrid(board)
+
+			r := g.Move(tt.dir)
+			if !r.Moved {
+				t.Fatalf("Move(%q) did not move", tt.dir)
+			}
+			if r.Score != tt.score || g.Score != tt.score {
+				t.Errorf("Move(%q) score = %d (game %d), want %d", tt.dir, r.Score, g.Score, tt.score)
+			}
+			if r.Spawn == nil {
+				t.Fatalf("Move(%q) did not spawn a tile", tt.dir)
+			}
+			if v := tt.want[r.Spawn.At.Y][r.Spawn.At.X]; v != 0 {
+				t.Errorf("Move(%q) spawned on %v, which holds %d", tt.dir, r.Spawn.At, v)
+			}
+
+			// 去掉新方块之后应该正好是推完的棋盘
+			got := CloneGrid(g.Grid)
+			got[r.Spawn.At.Y][r.Spawn.At.X] = 0
+			if !slices.EqualFunc(got, tt.want, slices.Equal[[]int]) {
+				t.Errorf("Move(%q) = %v, want %v", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoveWithoutChange(t *testing.T) {
+	g := NewGame2048(1)
+	g.Grid = [][]int{
+		{2, 4, 0, 0},
+		{4, 2, 0, 0},
+		{0, 0, 0, 0},
+		{0, 0, 0, 0},
+	}
+	before := CloneGrid(g.Grid)
+
+	r := g.Move("left")
+	if r.Moved || r.Spawn != nil || r.Score != 0 {
+		t.Errorf("Move on a packed board = %+v, want no change", r)
+	}
+	if !slices.EqualFunc(g.Grid, before, slices.Equal[[]int]) {
+		t.Errorf("board changed to %v", g.Grid)
+	}
+	if len(g.Actions) != 0 {
+		t.Errorf("recorded %d actions for a move that did nothing", len(g.Actions))
+	}
+}
+
+func tileSum(grid [][]int) int {
+	sum := 0
+	for _, row := range grid {
+		for _, v := range row {
+			sum += v
+		}
+	}
+	return sum
+}
+
+func FuzzSlide(f *testing.F) {
+	f.Add(int64(1), uint8(4), []byte{0, 1, 2, 3})
+	f.Add(int64(42), uint8(3), []byte{2, 2, 2, 0, 0, 3, 1})
+	f.Add(int64(-7), uint8(8), []byte{3, 3, 3, 3, 1, 1, 0})
+
+	f.Fuzz(func(t *testing.T, seed int64, size uint8, moves []byte) {
+		cfg := Game2048Config{
+			Size:   Min2048Size + int(size)%(Max2048Size-Min2048Size+1),
+			Target: 2048,
+		}
+		g := NewCustomGame2048(cfg, seed)
+
+		for _, m := range moves {
+			dir := Directions[int(m)%len(Directions)]
+			before := tileSum(g.Grid)
+			score := g.Score
+
+			r := g.Move(dir)
+
+			merged := 0
+			for _, mg := range r.Merges {
+				merged += mg.Value
+			}
+			if r.Score != merged {
+				t.Fatalf("Move(%q): score %d, merges add up to %d", dir, r.Score, merged)
+			}
+			if g.Score != score+r.Score {
+				t.Fatalf("Move(%q): game score %d, want %d", dir, g.Score, score+r.Score)
+			}
+
+			after := tileSum(g.Grid)
+			if !r.Moved {
+				if r.Spawn != nil || after != before {
+					t.Fatalf("Move(%q) did not move but the tile sum went %d -> %d", dir, before, after)
+				}
+				continue
+			}
+			if r.Spawn == nil {
+				t.Fatalf("Move(%q) moved but spawned nothing", dir)
+			}
+			if after != before+r.Spawn.Value {
+				t.Fatalf("Move(%q): tile sum %d -> %d, spawn %d", dir, before, after, r.Spawn.Value)
+			}
+		}
+	})
+}
+
+func TestDecision(t *testing.T) {
+	won := func(t *testing.T) *Game2048 {
+		g := NewCustomGame2048(Game2048Config{Size: 4, Target: 8}, 1)
+		g.Grid = [][]int{
+			{4, 4, 0, 0},
+			{0, 0, 0, 0},
+			{0, 0, 0, 0},
+			{0, 0, 0, 2},
+		}
+		g.Move("left")
+		if !g.Deciding() {
+			t.Fatalf("reaching the target did not ask for a decision: %+v", g)
+		}
+		return g
+	}
+	last := func(g *Game2048) ActionKind {
+		return g.Actions[len(g.Actions)-1].Kind
+	}
+
+	t.Run("continue", func(t *testing.T) {
+		g := won(t)
+		if !g.Apply(Action{Kind: ActionContinue}) {
+			t.Fatal("Apply(continue) did nothing")
+		}
+		if g.Deciding() || g.GameOver || !g.Continued {
+			t.Errorf("after continuing: Deciding %v, GameOver %v, Continued %v", g.Deciding(), g.GameOver, g.Continued)
+		}
+		if k := last(g); k != ActionContinue {
+			t.Errorf("last action = %q, want %q", k, ActionContinue)
+		}
+		if g.Continue() {
+			t.Error("continued twice")
+		}
+	})
+
+	t.Run("finish", func(t *testing.T) {
+		g := won(t)
+		if !g.Apply(Action{Kind: ActionFinish}) {
+			t.Fatal("Apply(finish) did nothing")
+		}
+		if g.Deciding() || !g.GameOver || g.Continued {
+			t.Errorf("after finishing: Deciding %v, GameOver %v, Continued %v", g.Deciding(), g.GameOver, g.Continued)
+		}
+		if k := last(g); k != ActionFinish {
+			t.Errorf("last action = %q, want %q", k, ActionFinish)
+		}
+		if g.Finish() || g.Continue() {
+			t.Error("decided again after the game was over")
+		}
+	})
+
+	t.Run("before the target", func(t *testing.T) {
+		g := NewGame2048(1)
+		if g.Continue() || g.Finish() || len(g.Actions) != 0 {
+			t.Errorf("decided before reaching the target: %v", g.Actions)
+		}
+	})
+}
+
+func TestUndoAfterGameOver(t *testing.T) {
+	g := NewGame2048(1)
+	g.HistoryLimit = MaxUndoHistory
+	g.Grid = [][]int{
+		{2, 4, 2, 4},
+		{4, 2, 4, 2},
+		{32, 4, 2, 4},
+		{8, 16, 8, 0},
+	}
+	before := CloneGrid(g.Grid)
+
+	// 向右之后左下角出现的方块无论是 2 还是 4 都无路可走
+	g.Move("right")
+	if !g.GameOver {
+		t.Fatalf("board %v is not stuck", g.Grid)
+	}
+	if !g.CanUndo() || !g.Undo() {
+		t.Fatal("could not undo the move that ended the game")
+	}
+	if g.GameOver || !slices.EqualFunc(g.Grid, before, slices.Equal[[]int]) {
+		t.Errorf("after undo: GameOver %v, board %v, want %v", g.GameOver, g.Grid, before)
+	}
+
+	g = NewCustomGame2048(Game2048Config{Size: 4, Target: 8}, 1)
+	g.HistoryLimit = MaxUndoHistory
+	g.Grid = [][]int{
+		{4, 4, 0, 0},
+		{0, 0, 0, 0},
+		{0, 0, 0, 0},
+		{0, 0, 0, 2},
+	}
+	g.Move("left")
+	g.Finish()
+	if g.CanUndo() || g.Undo() {
+		t.Error("undid a game the player chose to finish")
+	}
+}
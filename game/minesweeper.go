@@ -1,7 +1,10 @@
 package game
 
 import (
-	"math/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
 	"time"
 )
 
@@ -11,6 +14,7 @@ const (
 	CellHidden CellState = iota
 	CellRevealed
 	CellFlagged
+	CellQuestion // 问号标记，只是提醒，不算作旗子
 )
 
 type Cell struct {
@@ -28,30 +32,135 @@ type Minesweeper struct {
 	Revealed  int
 	GameOver  bool
 	Won       bool
+	Seed      int64    // 决定地雷位置的种子
+	Actions   []Action // 所有有效的操作，用于回放
+
+	// 地雷在第一次翻开时才布置，保证第一次翻开不会踩雷
+	FirstClick  FirstClickSafety
+	MinesPlaced bool
+
+	// Exploded 是踩到的雷，标记错误时一次连开可能踩到多个
+	Exploded []Point
+
+	// NoGuess 为 true 时只接受从第一次翻开的格子出发、仅靠推理就能解开的雷区。
+	// 用完尝试的预算都没有生成这样的雷区时 NoGuessFailed 为 true，
+	// 这一局使用最后一次生成的雷区，可能需要猜测
+	NoGuess       bool
+	NoGuessFailed bool
+
+	// Questions 为 true 时 ToggleFlag 在 隐藏 → 旗子 → 问号 → 隐藏 之间循环
+	Questions bool
+
+	// 计时从第一次翻开开始，到游戏结束为止。暂停时 PausedAt 为暂停的时刻，
+	// 继续时 StartTime 向后推移暂停的时长，因此用时总是 结束时刻 - StartTime
 	StartTime time.Time
+	EndTime   time.Time
+	PausedAt  time.Time
+
+	rng *rand.Rand
+	src *rand.PCG
 }
 
+// FirstClickSafety 决定第一次翻开时哪些格子保证没有雷。
+type FirstClickSafety int
+
+const (
+	SafeNeighbors FirstClickSafety = iota // 翻开的格子和周围八格都没有雷
+	SafeCell                              // 只保证翻开的格子没有雷
+)
+
 type Difficulty int
 
 const (
 	Easy Difficulty = iota
 	Medium
 	Hard
+	Custom // 由玩家指定大小和雷数
 )
 
-func NewMinesweeper(difficulty Difficulty) *Minesweeper {
-	var width, height, mineCount int
+// String 返回难度的标识，用于保存记录。
+func (d Difficulty) String() string {
+	switch d {
+	case Medium:
+		return "medium"
+	case Hard:
+		return "hard"
+	case Custom:
+		return "custom"
+	default:
+		return "easy"
+	}
+}
+
+// ParseDifficulty 把 String 返回的标识解析为预设难度，不接受 Custom。
+func ParseDifficulty(s string) (Difficulty, bool) {
+	for _, d := range []Difficulty{Easy, Medium, Hard} {
+		if d.String() == s {
+			return d, true
+		}
+	}
+	return Easy, false
+}
 
-	switch difficulty {
-	case Easy:
-		width, height, mineCount = 9, 9, 10
+// Config 返回预设难度的雷区大小和雷数，Custom 没有预设，返回简单难度的设置。
+func (d Difficulty) Config() MinesweeperConfig {
+	switch d {
 	case Medium:
-		width, height, mineCount = 16, 16, 40
+		return MinesweeperConfig{Width: 16, Height: 16, Mines: 40}
 	case Hard:
-		width, height, mineCount = 30, 16, 99
+		return MinesweeperConfig{Width: 30, Height: 16, Mines: 99}
 	default:
-		width, height, mineCount = 9, 9, 10
+		return MinesweeperConfig{Width: 9, Height: 9, Mines: 10}
 	}
+}
+
+// 自定义雷区的大小范围
+const (
+	MinBoardSize   = 5
+	MaxBoardWidth  = 50
+	MaxBoardHeight = 30
+)
+
+// MinesweeperConfig 描述雷区的大小和雷数。
+type MinesweeperConfig struct {
+	Width  int
+	Height int
+	Mines  int
+}
+
+func (c MinesweeperConfig) String() string {
+	return fmt.Sprintf("%dx%d-%d", c.Width, c.Height, c.Mines)
+}
+
+// Validate 检查雷区设置是否可用，雷数必须少于格子数减去第一次翻开时的安全区。
+func (c MinesweeperConfig) Validate(firstClick FirstClickSafety) error {
+	switch {
+	case c.Width < MinBoardSize || c.Width > MaxBoardWidth:
+		return fmt.Errorf("宽度必须在 %d 到 %d 之间", MinBoardSize, MaxBoardWidth)
+	case c.Height < MinBoardSize || c.Height > MaxBoardHeight:
+		return fmt.Errorf("高度必须在 %d 到 %d 之间", MinBoardSize, MaxBoardHeight)
+	case c.Mines < 1:
+		return errors.New("至少要有 1 个雷")
+	}
+
+	safe := 9
+	if firstClick == SafeCell {
+		safe = 1
+	}
+	if limit := c.Width*c.Height - safe; c.Mines >= limit {
+		return fmt.Errorf("雷数必须少于 %d", limit)
+	}
+	return nil
+}
+
+// NewMinesweeper 创建一局新游戏，相同的难度和 seed 总是得到相同的雷区。
+func NewMinesweeper(difficulty Difficulty, seed int64) *Minesweeper {
+	return NewCustomMinesweeper(difficulty.Config(), seed)
+}
+
+// NewCustomMinesweeper 按 cfg 创建一局新游戏，调用方应先用 Validate 检查 cfg。
+func NewCustomMinesweeper(cfg MinesweeperConfig, seed int64) *Minesweeper {
+	width, height, mineCount := cfg.Width, cfg.Height, cfg.Mines
 
 	ms := &Minesweeper{
 		Width:     width,
@@ -61,35 +170,148 @@ func NewMinesweeper(difficulty Difficulty) *Minesweeper {
 		Revealed:  0,
 		GameOver:  false,
 		Won:       false,
-		StartTime: time.Now(),
+		Seed:      seed,
 	}
+	ms.rng, ms.src = newRand(seed)
 
 	ms.Grid = make([][]Cell, height)
 	for y := range ms.Grid {
 		ms.Grid[y] = make([]Cell, width)
 	}
 
-	ms.placeMines()
-	ms.calculateAdjacent()
-
 	return ms
 }
 
-func (ms *Minesweeper) placeMines() {
-	rand.Seed(time.Now().UnixNano())
+// Opening 返回统一开局时翻开的格子，也就是雷区的中心。
+func (ms *Minesweeper) Opening() Point {
+	return Point{X: ms.Width / 2, Y: ms.Height / 2}
+}
+
+// Open 替玩家翻开 Opening。雷区取决于种子和第一次翻开的位置，所以从同一个
+// 格子开局的玩家会得到同样的雷区，每日挑战用它保证所有人的棋局相同。
+// 开局的翻开和普通的翻开一样记入操作，回放不需要特殊处理，但不开始计时，
+// 计时从玩家的第一次操作开始，无论是翻开、标记还是连开。
+func (ms *Minesweeper) Open() {
+	p := ms.Opening()
+	ms.Reveal(p.X, p.Y)
+	ms.StartTime = time.Time{}
+}
+
+// 生成无需猜测的雷区时最多尝试 maxNoGuessAttempts 次，并且所有尝试的格子数
+// 加起来不超过 noGuessCellBudget。大雷区每次尝试都更慢，也更难成功，按格子数
+// 限制让最大的自定义雷区也能在一秒内放弃。预算不用时间计算，同一个种子在任何
+// 机器上、回放时都得到同样的雷区。用完之后使用最后一次生成的雷区并设置 NoGuessFailed
+const (
+	maxNoGuessAttempts = 500
+	noGuessCellBudget  = 60_000
+)
+
+// placeMines 在第一次翻开 (x, y) 时布置地雷，避开安全区。
+func (ms *Minesweeper) placeMines(x, y int) {
+	safe := ms.safeZone(x, y)
+	attempts := max(1, min(maxNoGuessAttempts, noGuessCellBudget/(ms.Width*ms.Height)))
+	for i := 1; ; i++ {
+		ms.layMines(safe)
+		if !ms.NoGuess || ms.solvableFrom(x, y) {
+			break
+		}
+		if i >= attempts {
+			ms.NoGuessFailed = true
+			break
+		}
+	}
+	ms.MinesPlaced = true
+}
+
+// safeZone 返回第一次翻开 (x, y) 时不能放雷的格子
+func (ms *Minesweeper) safeZone(x, y int) func(cx, cy int) bool {
+	safe := func(cx, cy int) bool {
+		if ms.FirstClick == SafeCell {
+			return cx == x && cy == y
+		}
+		return abs(cx-x) <= 1 && abs(cy-y) <= 1
+	}
+
+	// 棋盘太小、放不下安全区时退回到只保护翻开的格子
+	safeCount := 0
+	for cy := 0; cy < ms.Height; cy++ {
+		for cx := 0; cx < ms.Width; cx++ {
+			if safe(cx, cy) {
+				safeCount++
+			}
+		}
+	}
+	if ms.Width*ms.Height-safeCount < ms.MineCount {
+		return func(cx, cy int) bool { return cx == x && cy == y }
+	}
+	return safe
+}
+
+// layMines 清空雷区后重新布雷。所有格子先按种子打乱顺序，再依次跳过
+// 安全区取前 MineCount 个，因此同一个种子下，从不同位置开局得到的
+// 雷区也只在安全区附近不同。
+func (ms *Minesweeper) layMines(safe func(cx, cy int) bool) {
+	for y := range ms.Grid {
+		for x := range ms.Grid[y] {
+			ms.Grid[y][x].IsMine = false
+			ms.Grid[y][x].Adjacent = 0
+		}
+	}
+
 	minesPlaced := 0
+	for _, i := range ms.rng.Perm(ms.Width * ms.Height) {
+		if minesPlaced == ms.MineCount {
+			break
+		}
+		cx, cy := i%ms.Width, i/ms.Width
+		if safe(cx, cy) {
+			continue
+		}
+		ms.Grid[cy][cx].IsMine = true
+		minesPlaced++
+	}
 
-	for minesPlaced < ms.MineCount {
-		x := rand.Intn(ms.Width)
-		y := rand.Intn(ms.Height)
+	ms.calculateAdjacent()
+}
 
-		if !ms.Grid[y][x].IsMine {
-			ms.Grid[y][x].IsMine = true
-			minesPlaced++
+// solvableFrom 模拟玩家从 (x, y) 开局、每次只翻开求解器推断出的安全格子，
+// 报告能否不靠猜测赢下这一局
+func (ms *Minesweeper) solvableFrom(x, y int) bool {
+	sim := &Minesweeper{
+		Grid:      make([][]Cell, ms.Height),
+		Width:     ms.Width,
+		Height:    ms.Height,
+		MineCount: ms.MineCount,
+	}
+	for i, row := range ms.Grid {
+		sim.Grid[i] = append([]Cell(nil), row...)
+	}
+
+	sim.revealCell(x, y)
+	for {
+		sim.checkWin()
+		if sim.Won {
+			return true
+		}
+		solver := NewSolver(sim)
+		solver.Solve()
+		safe := solver.SafeCells()
+		if len(safe) == 0 {
+			return false
+		}
+		for _, p := range safe {
+			sim.revealCell(p.X, p.Y)
 		}
 	}
 }
 
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
 func (ms *Minesweeper) calculateAdjacent() {
 	for y := 0; y < ms.Height; y++ {
 		for x := 0; x < ms.Width; x++ {
@@ -117,7 +339,7 @@ func (ms *Minesweeper) calculateAdjacent() {
 }
 
 func (ms *Minesweeper) Reveal(x, y int) bool {
-	if x < 0 || x >= ms.Width || y < 0 || y >= ms.Height {
+	if ms.GameOver || x < 0 || x >= ms.Width || y < 0 || y >= ms.Height {
 		return false
 	}
 
@@ -127,8 +349,16 @@ func (ms *Minesweeper) Reveal(x, y int) bool {
 		return false
 	}
 
+	ms.startClock()
+	ms.record(ActionReveal, x, y)
+
+	if !ms.MinesPlaced {
+		ms.placeMines(x, y)
+	}
+
 	if cell.IsMine {
-		ms.GameOver = true
+		ms.Exploded = append(ms.Exploded, Point{X: x, Y: y})
+		ms.end()
 		return true
 	}
 
@@ -137,6 +367,63 @@ func (ms *Minesweeper) Reveal(x, y int) bool {
 	return true
 }
 
+// Chord 在已翻开的数字 (x, y) 周围的标记数等于该数字时，翻开周围所有
+// 未标记的格子。标记错误时会踩到雷并结束游戏。返回是否执行了连开。
+func (ms *Minesweeper) Chord(x, y int) bool {
+	if ms.GameOver || x < 0 || x >= ms.Width || y < 0 || y >= ms.Height {
+		return false
+	}
+
+	cell := ms.Grid[y][x]
+	if cell.State != CellRevealed || cell.Adjacent == 0 {
+		return false
+	}
+
+	flags := 0
+	ms.eachNeighbour(x, y, func(nx, ny int) {
+		if ms.Grid[ny][nx].State == CellFlagged {
+			flags++
+		}
+	})
+	if flags != cell.Adjacent {
+		return false
+	}
+
+	ms.startClock()
+	ms.record(ActionChord, x, y)
+
+	ms.eachNeighbour(x, y, func(nx, ny int) {
+		n := &ms.Grid[ny][nx]
+		if n.State != CellHidden && n.State != CellQuestion {
+			return
+		}
+		if n.IsMine {
+			ms.Exploded = append(ms.Exploded, Point{X: nx, Y: ny})
+			return
+		}
+		ms.revealCell(nx, ny)
+	})
+
+	if len(ms.Exploded) > 0 {
+		ms.end()
+	} else {
+		ms.checkWin()
+	}
+	return true
+}
+
+// eachNeighbour 对 (x, y) 周围在棋盘内的每个格子调用 fn
+func (ms *Minesweeper) eachNeighbour(x, y int, fn func(nx, ny int)) {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			nx, ny := x+dx, y+dy
+			if (dx != 0 || dy != 0) && nx >= 0 && nx < ms.Width && ny >= 0 && ny < ms.Height {
+				fn(nx, ny)
+			}
+		}
+	}
+}
+
 func (ms *Minesweeper) revealCell(x, y int) {
 	if x < 0 || x >= ms.Width || y < 0 || y >= ms.Height {
 		return
@@ -163,8 +450,9 @@ func (ms *Minesweeper) revealCell(x, y int) {
 	}
 }
 
+// ToggleFlag 切换 (x, y) 的标记，Flags 只统计旗子，不包括问号。
 func (ms *Minesweeper) ToggleFlag(x, y int) {
-	if x < 0 || x >= ms.Width || y < 0 || y >= ms.Height {
+	if ms.GameOver || x < 0 || x >= ms.Width || y < 0 || y >= ms.Height {
 		return
 	}
 
@@ -174,26 +462,143 @@ func (ms *Minesweeper) ToggleFlag(x, y int) {
 		return
 	}
 
-	if cell.State == CellFlagged {
+	// 布雷之前的标记不开始计时，已经开局时标记和翻开一样开始计时
+	if ms.MinesPlaced {
+		ms.startClock()
+	}
+	ms.record(ActionFlag, x, y)
+
+	switch cell.State {
+	case CellFlagged:
 		cell.State = CellHidden
+		if ms.Questions {
+			cell.State = CellQuestion
+		}
 		ms.Flags--
-	} else {
+	case CellQuestion:
+		cell.State = CellHidden
+	default:
 		cell.State = CellFlagged
 		ms.Flags++
 	}
 }
 
+func (ms *Minesweeper) record(kind ActionKind, x, y int) {
+	ms.Actions = append(ms.Actions, Action{Kind: kind, X: x, Y: y, At: ms.GetElapsedTime()})
+}
+
+// Apply 执行一次记录下来的操作，用于回放。
+func (ms *Minesweeper) Apply(a Action) bool {
+	switch a.Kind {
+	case ActionReveal:
+		return ms.Reveal(a.X, a.Y)
+	case ActionFlag:
+		ms.ToggleFlag(a.X, a.Y)
+		return true
+	case ActionChord:
+		return ms.Chord(a.X, a.Y)
+	}
+	return false
+}
+
 func (ms *Minesweeper) checkWin() {
 	totalCells := ms.Width * ms.Height
 	if ms.Revealed == totalCells-ms.MineCount {
 		ms.Won = true
-		ms.GameOver = true
+		ms.end()
+	}
+}
+
+// end 结束游戏并停止计时
+func (ms *Minesweeper) end() {
+	ms.GameOver = true
+	ms.EndTime = time.Now()
+	if !ms.PausedAt.IsZero() {
+		ms.EndTime = ms.PausedAt
+		ms.PausedAt = time.Time{}
+	}
+}
+
+// startClock 在计时还没有开始时开始计时
+func (ms *Minesweeper) startClock() {
+	if ms.StartTime.IsZero() {
+		ms.StartTime = time.Now()
+	}
+}
+
+// Started 返回计时是否已经开始
+func (ms *Minesweeper) Started() bool {
+	return !ms.StartTime.IsZero()
+}
+
+// Paused 返回游戏是否处于暂停状态
+func (ms *Minesweeper) Paused() bool {
+	return !ms.PausedAt.IsZero()
+}
+
+// Pause 暂停计时，只有计时开始后、游戏结束前才能暂停
+func (ms *Minesweeper) Pause() {
+	if ms.Started() && !ms.GameOver && !ms.Paused() {
+		ms.PausedAt = time.Now()
+	}
+}
+
+// Resume 从暂停中恢复计时，暂停的时间不计入用时
+func (ms *Minesweeper) Resume() {
+	if ms.Paused() {
+		ms.StartTime = ms.StartTime.Add(time.Since(ms.PausedAt))
+		ms.PausedAt = time.Time{}
+	}
+}
+
+// SetElapsed 把进行中对局的用时设为 d，读档时用来跳过离线的时间
+func (ms *Minesweeper) SetElapsed(d time.Duration) {
+	if !ms.Started() || ms.GameOver {
+		return
+	}
+	now := time.Now()
+	ms.StartTime = now.Add(-d)
+	if ms.Paused() {
+		ms.PausedAt = now
 	}
 }
 
+// GetElapsedTime 返回用时，还没有翻开格子时为 0，游戏结束后不再增加
 func (ms *Minesweeper) GetElapsedTime() time.Duration {
-	if ms.GameOver {
-		return time.Since(ms.StartTime)
+	switch {
+	case !ms.Started():
+		return 0
+	case ms.GameOver:
+		return ms.EndTime.Sub(ms.StartTime)
+	case ms.Paused():
+		return ms.PausedAt.Sub(ms.StartTime)
 	}
 	return time.Since(ms.StartTime)
 }
+
+type minesweeperFields Minesweeper
+
+// MarshalJSON 连同随机数生成器的状态一起保存。
+func (ms *Minesweeper) MarshalJSON() ([]byte, error) {
+	state, err := ms.src.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		*minesweeperFields
+		RNG []byte
+	}{(*minesweeperFields)(ms), state})
+}
+
+func (ms *Minesweeper) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*minesweeperFields
+		RNG []byte
+	}{minesweeperFields: (*minesweeperFields)(ms)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	ms.rng, ms.src, err = restoreRand(aux.RNG)
+	return err
+}
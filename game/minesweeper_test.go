@@ -0,0 +1,176 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlagAndChordWinIsTimed(t *testing.T) {
+	ms := NewMinesweeper(Medium, DailySeed("2026-10-16"))
+	ms.Open()
+	if ms.Started() {
+		t.Fatal("Open started the clock")
+	}
+
+	// 只用标记和连开：标出数字周围所有的雷，再在数字上连开
+	for progress := true; progress && !ms.GameOver; {
+		progress = false
+		for y := 0; y < ms.Height; y++ {
+			for x := 0; x < ms.Width; x++ {
+				if ms.Grid[y][x].State != CellRevealed || ms.Grid[y][x].Adjacent == 0 {
+					continue
+				}
+				ms.eachNeighbour(x, y, func(nx, ny int) {
+					if ms.Grid[ny][nx].IsMine && ms.Grid[ny][nx].State != CellFlagged {
+						ms.ToggleFlag(nx, ny)
+						if !ms.Started() {
+							t.Fatal("flagging did not start the clock")
+						}
+						// 不用真的等待，直接把用时拨到一分钟
+						if ms.GetElapsedTime() < time.Minute {
+							ms.SetElapsed(time.Minute)
+						}
+					}
+				})
+				if ms.Chord(x, y) {
+					progress = true
+				}
+			}
+		}
+	}
+
+	if !ms.Won {
+		t.Fatalf("revealed %d of %d cells without winning", ms.Revealed, ms.Width*ms.Height-ms.MineCount)
+	}
+	if d := ms.GetElapsedTime(); d < time.Minute {
+		t.Errorf("flag-and-chord win took %v, want at least the minute set while playing", d)
+	}
+}
+
+// newTestBoard 按 rows 布置好地雷，"*" 是雷，其余字符是空格子
+func newTestBoard(rows ...string) *Minesweeper {
+	mines := 0
+	for _, row := range rows {
+		for _, c := range row {
+			if c == '*' {
+				mines++
+			}
+		}
+	}
+	ms := NewCustomMinesweeper(MinesweeperConfig{Width: len(rows[0]), Height: len(rows), Mines: mines}, 1)
+	for y, row := range rows {
+		for x, c := range row {
+			ms.Grid[y][x].IsMine = c == '*'
+		}
+	}
+	ms.calculateAdjacent()
+	ms.MinesPlaced = true
+	return ms
+}
+
+func TestSolvableFrom(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []string
+		x, y int
+		want bool
+	}{
+		{
+			name: "一次展开就赢",
+			rows: []string{".....", ".....", ".....", ".....", "....*"},
+			want: true,
+		},
+		{
+			name: "靠数字推理",
+			rows: []string{"..*..", ".....", ".....", ".....", "....."},
+			x:    0, y: 4,
+			want: true,
+		},
+		{
+			name: "开局只有一个数字",
+			rows: []string{".....", ".*...", ".....", "...*.", "....."},
+			want: false,
+		},
+		{
+			name: "角落里的五五开",
+			rows: []string{"*....", ".....", "**...", ".....", "....."},
+			x:    4, y: 4,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := newTestBoard(tt.rows...)
+			if got := ms.solvableFrom(tt.x, tt.y); got != tt.want {
+				t.Errorf("solvableFrom(%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+			}
+			if ms.Revealed != 0 {
+				t.Errorf("solvableFrom revealed %d cells on the real board", ms.Revealed)
+			}
+		})
+	}
+}
+
+func TestNoGuessPresets(t *testing.T) {
+	for _, d := range []Difficulty{Easy, Medium, Hard} {
+		for seed := int64(1); seed <= 3; seed++ {
+			ms := NewMinesweeper(d, seed)
+			ms.NoGuess = true
+			ms.Open()
+			if ms.NoGuessFailed {
+				t.Errorf("%v seed %d: no-guess generation fell back", d, seed)
+				continue
+			}
+			// 只翻开求解器推断出的安全格子，应该能一路赢到底
+			for !ms.GameOver {
+				solver := NewSolver(ms)
+				solver.Solve()
+				safe := solver.SafeCells()
+				if len(safe) == 0 {
+					break
+				}
+				for _, p := range safe {
+					ms.Reveal(p.X, p.Y)
+				}
+			}
+			if !ms.Won {
+				t.Errorf("%v seed %d: board needs guessing after %d reveals", d, seed, ms.Revealed)
+			}
+		}
+	}
+}
+
+func TestNoMovesAfterGameOver(t *testing.T) {
+	ms := newTestBoard("*...*", ".....", ".....", ".....", ".....")
+	ms.Reveal(0, 0)
+	if !ms.GameOver || len(ms.Exploded) != 1 {
+		t.Fatalf("revealing a mine: GameOver = %v, exploded = %v", ms.GameOver, ms.Exploded)
+	}
+	end, actions := ms.EndTime, len(ms.Actions)
+
+	if ms.Reveal(4, 0) {
+		t.Error("Reveal succeeded after the game ended")
+	}
+	ms.ToggleFlag(2, 2)
+	if len(ms.Exploded) != 1 || ms.EndTime != end || len(ms.Actions) != actions || ms.Flags != 0 {
+		t.Errorf("moves after game over changed the game: exploded = %v, actions = %d, flags = %d",
+			ms.Exploded, len(ms.Actions), ms.Flags)
+	}
+}
+
+func TestNoGuessBudget(t *testing.T) {
+	// 密度很高的最大雷区很难无需猜测，生成应该很快放弃，而不是卡住界面
+	cfg := MinesweeperConfig{Width: MaxBoardWidth, Height: MaxBoardHeight, Mines: 350}
+	for seed := int64(1); seed <= 3; seed++ {
+		ms := NewCustomMinesweeper(cfg, seed)
+		ms.NoGuess = true
+		start := time.Now()
+		ms.Reveal(cfg.Width/2, cfg.Height/2)
+		if d := time.Since(start); d > 3*time.Second {
+			t.Errorf("seed %d: generating took %v", seed, d)
+		}
+		if !ms.MinesPlaced {
+			t.Errorf("seed %d: mines were not placed", seed)
+		}
+	}
+}
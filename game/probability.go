@@ -0,0 +1,301 @@
+package game
+
+import (
+	"math"
+	"slices"
+)
+
+// maxEnumeration 是枚举一个边界区域时最多尝试的节点数，超过后改用估计值
+const maxEnumeration = 1 << 20
+
+// region 是边界上一组互相关联的未知格子，以及约束它们的数字
+type region struct {
+	cells []int
+	cons  []constraint
+
+	// ways[k] 是这组格子中恰好有 k 个雷的布置数，
+	// hits[k][i] 是其中 cells[i] 是雷的布置数
+	ways []float64
+	hits [][]float64
+}
+
+// MineProbabilities 返回每个还没有翻开、也没有推断出结论的格子是雷的概率。
+// 与数字相邻的格子按区域枚举所有满足数字的布置，再按总雷数为每种布置加权；
+// 其余格子平分剩下的雷。推断出一定安全或一定是雷的格子概率为 0 或 1。
+func (s *Solver) MineProbabilities() map[Point]float64 {
+	s.Solve()
+
+	probs := make(map[Point]float64)
+	frontier := make(map[int]bool)
+	regions := s.regions()
+	for _, r := range regions {
+		for _, c := range r.cells {
+			frontier[c] = true
+		}
+	}
+
+	var interior []int
+	for i := 0; i < s.ms.Width*s.ms.Height; i++ {
+		switch {
+		case s.revealed(i):
+		case s.safe[i]:
+			probs[s.point(i)] = 0
+		case s.mines[i]:
+			probs[s.point(i)] = 1
+		case !frontier[i]:
+			interior = append(interior, i)
+		}
+	}
+	remaining := s.ms.MineCount - len(s.mines)
+
+	// interiorWeight(m) 正比于其余格子中放 m 个雷的方法数，用对数避免溢出
+	maxLog := math.Inf(-1)
+	for m := 0; m <= remaining; m++ {
+		maxLog = math.Max(maxLog, logChoose(len(interior), m))
+	}
+	interiorWeight := func(m int) float64 {
+		if m < 0 || m > len(interior) {
+			return 0
+		}
+		return math.Exp(logChoose(len(interior), m) - maxLog)
+	}
+
+	total := convolve(regions, -1)
+	z := 0.0
+	interiorMines := 0.0
+	for k, w := range total {
+		iw := w * interiorWeight(remaining-k)
+		z += iw
+		interiorMines += iw * float64(remaining-k)
+	}
+	if z == 0 {
+		// 玩家看到的信息互相矛盾，不会在正常对局中出现
+		return probs
+	}
+
+	for i, r := range regions {
+		others := convolve(regions, i)
+		for k, hits := range r.hits {
+			// 这组格子有 k 个雷时，其他区域和其余格子的权重之和
+			rest := 0.0
+			for j, w := range others {
+				rest += w * interiorWeight(remaining-k-j)
+			}
+			for c, h := range hits {
+				probs[s.point(r.cells[c])] += h * rest / z
+			}
+		}
+	}
+	for _, c := range interior {
+		probs[s.point(c)] = interiorMines / float64(len(interior)) / z
+	}
+	return probs
+}
+
+// Hint 为玩家挑一个要翻开的格子：有一定安全的格子时返回它和概率 0，
+// 否则返回是雷的概率最低的格子。标记了旗子的格子不会被选中。
+func (s *Solver) Hint() (Point, float64, bool) {
+	var best Point
+	bestProb, found := 2.0, false
+	for p, prob := range s.MineProbabilities() {
+		if s.ms.Grid[p.Y][p.X].State == CellFlagged {
+			continue
+		}
+		// 概率相同时选最靠左上的格子，让提示稳定
+		if prob < bestProb || prob == bestProb && (p.Y < best.Y || p.Y == best.Y && p.X < best.X) {
+			best, bestProb, found = p, prob, true
+		}
+	}
+	return best, bestProb, found
+}
+
+// regions 把边界上的未知格子按共享的数字分成互不相关的区域，并枚举每个区域
+func (s *Solver) regions() []*region {
+	cons := s.constraints()
+	byCell := make(map[int][]int)
+	for i, c := range cons {
+		for _, cell := range c.cells {
+			byCell[cell] = append(byCell[cell], i)
+		}
+	}
+
+	var regions []*region
+	seen := make(map[int]bool)
+	for i := range cons {
+		if seen[i] {
+			continue
+		}
+		r := &region{}
+		cells := make(map[int]bool)
+		queue := []int{i}
+		seen[i] = true
+		for len(queue) > 0 {
+			c := cons[queue[0]]
+			queue = queue[1:]
+			r.cons = append(r.cons, c)
+			for _, cell := range c.cells {
+				if cells[cell] {
+					continue
+				}
+				cells[cell] = true
+				r.cells = append(r.cells, cell)
+				for _, j := range byCell[cell] {
+					if !seen[j] {
+						seen[j] = true
+						queue = append(queue, j)
+					}
+				}
+			}
+		}
+		slices.Sort(r.cells)
+		s.enumerate(r)
+		regions = append(regions, r)
+	}
+	return regions
+}
+
+// enumerate 回溯枚举区域中所有满足数字的布置，节点太多时改用估计值
+func (s *Solver) enumerate(r *region) {
+	n := len(r.cells)
+	index := make(map[int]int, n)
+	for i, c := range r.cells {
+		index[c] = i
+	}
+	// 每个格子参与的约束，以及每个约束的剩余雷数和剩余未定格子数
+	cellCons := make([][]int, n)
+	need := make([]int, len(r.cons))
+	left := make([]int, len(r.cons))
+	for i, c := range r.cons {
+		need[i] = c.mines
+		left[i] = len(c.cells)
+		for _, cell := range c.cells {
+			cellCons[index[cell]] = append(cellCons[index[cell]], i)
+		}
+	}
+
+	r.ways = make([]float64, n+1)
+	r.hits = make([][]float64, n+1)
+	for k := range r.hits {
+		r.hits[k] = make([]float64, n)
+	}
+
+	mine := make([]bool, n)
+	nodes := 0
+	var visit func(i, k int) bool
+	visit = func(i, k int) bool {
+		if nodes++; nodes > maxEnumeration {
+			return false
+		}
+		if i == n {
+			r.ways[k]++
+			for c := range mine {
+				if mine[c] {
+					r.hits[k][c]++
+				}
+			}
+			return true
+		}
+		for _, isMine := range []bool{false, true} {
+			ok := true
+			for _, ci := range cellCons[i] {
+				left[ci]--
+				if isMine {
+					need[ci]--
+				}
+				if need[ci] < 0 || need[ci] > left[ci] {
+					ok = false
+				}
+			}
+			mine[i] = isMine
+			next := true
+			if ok {
+				next = visit(i+1, k+btoi(isMine))
+			}
+			for _, ci := range cellCons[i] {
+				left[ci]++
+				if isMine {
+					need[ci]++
+				}
+			}
+			mine[i] = false
+			if !next {
+				return false
+			}
+		}
+		return true
+	}
+	if !visit(0, 0) {
+		s.estimate(r)
+	}
+}
+
+// estimate 在区域太大无法枚举时，用各个数字的平均雷密度估计每个格子的概率
+func (s *Solver) estimate(r *region) {
+	n := len(r.cells)
+	density := make([]float64, n)
+	count := make([]int, n)
+	for _, c := range r.cons {
+		for _, cell := range c.cells {
+			i, _ := slices.BinarySearch(r.cells, cell)
+			density[i] += float64(c.mines) / float64(len(c.cells))
+			count[i]++
+		}
+	}
+
+	expected := 0.0
+	for i := range density {
+		density[i] /= float64(count[i])
+		expected += density[i]
+	}
+	k := int(math.Round(expected))
+	r.ways = make([]float64, n+1)
+	r.hits = make([][]float64, n+1)
+	for j := range r.hits {
+		r.hits[j] = make([]float64, n)
+	}
+	r.ways[k] = 1
+	copy(r.hits[k], density)
+}
+
+// convolve 返回除第 skip 个区域以外所有区域的总雷数分布，skip 为 -1 时包括所有区域
+func convolve(regions []*region, skip int) []float64 {
+	dist := []float64{1}
+	for i, r := range regions {
+		if i == skip {
+			continue
+		}
+		next := make([]float64, len(dist)+len(r.ways)-1)
+		for a, wa := range dist {
+			if wa == 0 {
+				continue
+			}
+			for b, wb := range r.ways {
+				next[a+b] += wa * wb
+			}
+		}
+		dist = next
+	}
+	return dist
+}
+
+// logChoose 返回 C(n, k) 的自然对数
+func logChoose(n, k int) float64 {
+	if k < 0 || k > n {
+		return math.Inf(-1)
+	}
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (s *Solver) point(i int) Point {
+	return Point{X: i % s.ms.Width, Y: i / s.ms.Width}
+}
@@ -0,0 +1,70 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMineProbabilities(t *testing.T) {
+	tests := []struct {
+		name     string
+		rows     []string
+		revealed []Point
+		want     map[Point]float64
+		hint     Point
+	}{
+		{
+			name:     "一定安全",
+			rows:     []string{"..*."},
+			revealed: []Point{{1, 0}, {3, 0}},
+			want:     map[Point]float64{{0, 0}: 0, {2, 0}: 1},
+			hint:     Point{0, 0},
+		},
+		{
+			name:     "一定是雷",
+			rows:     []string{"*..."},
+			revealed: []Point{{1, 0}, {2, 0}, {3, 0}},
+			want:     map[Point]float64{{0, 0}: 1},
+			hint:     Point{0, 0},
+		},
+		{
+			name:     "五五开",
+			rows:     []string{"*.."},
+			revealed: []Point{{1, 0}},
+			want:     map[Point]float64{{0, 0}: 0.5, {2, 0}: 0.5},
+			hint:     Point{0, 0},
+		},
+		{
+			name: "没有数字时平分",
+			rows: []string{"*...", "...."},
+			want: map[Point]float64{
+				{0, 0}: 0.125, {1, 0}: 0.125, {2, 0}: 0.125, {3, 0}: 0.125,
+				{0, 1}: 0.125, {1, 1}: 0.125, {2, 1}: 0.125, {3, 1}: 0.125,
+			},
+			hint: Point{0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := newTestBoard(tt.rows...)
+			for _, p := range tt.revealed {
+				ms.revealCell(p.X, p.Y)
+			}
+
+			got := NewSolver(ms).MineProbabilities()
+			if len(got) != len(tt.want) {
+				t.Errorf("MineProbabilities() = %v, want %v", got, tt.want)
+			}
+			for p, want := range tt.want {
+				if prob, ok := got[p]; !ok || math.Abs(prob-want) > 1e-9 {
+					t.Errorf("probability of %v = %v, want %v", p, prob, want)
+				}
+			}
+
+			hint, prob, ok := NewSolver(ms).Hint()
+			if !ok || hint != tt.hint || math.Abs(prob-tt.want[tt.hint]) > 1e-9 {
+				t.Errorf("Hint() = %v, %v, %v, want %v, %v", hint, prob, ok, tt.hint, tt.want[tt.hint])
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package game
+
+import (
+	"math/rand/v2"
+)
+
+// NewSeed 返回一个新的随机种子，数值不大以便分享给其他玩家。
+func NewSeed() int64 {
+	return rand.Int64N(1_000_000_000)
+}
+
+// newRand 返回由 seed 决定的随机数生成器，相同的种子总是得到相同的序列。
+// 同时返回底层的 PCG，以便把生成器的状态保存到存档中。
+func newRand(seed int64) (*rand.Rand, *rand.PCG) {
+	src := rand.NewPCG(uint64(seed), 0x9e3779b97f4a7c15)
+	return rand.New(src), src
+}
+
+// restoreRand 从 PCG.MarshalBinary 保存的状态恢复随机数生成器。
+func restoreRand(state []byte) (*rand.Rand, *rand.PCG, error) {
+	src := &rand.PCG{}
+	if err := src.UnmarshalBinary(state); err != nil {
+		return nil, nil, err
+	}
+	return rand.New(src), src, nil
+}
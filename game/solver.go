@@ -0,0 +1,211 @@
+package game
+
+import (
+	"sort"
+)
+
+// Point 是棋盘上的一个格子。
+type Point struct {
+	X, Y int
+}
+
+// Solver 只根据玩家能看到的信息（已翻开格子上的数字和总雷数）
+// 推断哪些未翻开的格子一定安全、哪些一定是雷，不会读取 IsMine。
+// 玩家的标记可能是错的，因此也不会被当作已知的雷。
+type Solver struct {
+	ms    *Minesweeper
+	safe  map[int]bool
+	mines map[int]bool
+}
+
+// constraint 表示 cells 中恰好有 mines 个雷
+type constraint struct {
+	cells []int
+	mines int
+}
+
+// NewSolver 为 ms 的当前局面创建求解器。
+func NewSolver(ms *Minesweeper) *Solver {
+	return &Solver{
+		ms:    ms,
+		safe:  make(map[int]bool),
+		mines: make(map[int]bool),
+	}
+}
+
+// Solve 反复应用推理规则直到得不出新的结论，返回是否得到了新的结论。
+func (s *Solver) Solve() bool {
+	progress := false
+	for s.step() {
+		progress = true
+	}
+	return progress
+}
+
+// SafeCells 返回推断出一定安全、但还没有翻开的格子。
+func (s *Solver) SafeCells() []Point {
+	var cells []int
+	for i := range s.safe {
+		if !s.revealed(i) {
+			cells = append(cells, i)
+		}
+	}
+	return s.points(cells)
+}
+
+// KnownMines 返回推断出一定是雷的格子。
+func (s *Solver) KnownMines() []Point {
+	var cells []int
+	for i := range s.mines {
+		cells = append(cells, i)
+	}
+	return s.points(cells)
+}
+
+// step 应用一轮推理规则，从简单到复杂，只要某条规则得出结论就返回 true
+func (s *Solver) step() bool {
+	cons := s.constraints()
+
+	// 单个数字：剩余雷数为 0 时其余格子安全，等于未知格子数时全是雷
+	changed := false
+	for _, c := range cons {
+		changed = s.settle(c.cells, c.mines) || changed
+	}
+	if changed {
+		return true
+	}
+
+	// 两个数字：A 的未知格子是 B 的子集时，B 比 A 多出的格子里有 B-A 个雷
+	byCell := make(map[int][]int)
+	for i, c := range cons {
+		for _, cell := range c.cells {
+			byCell[cell] = append(byCell[cell], i)
+		}
+	}
+	for i, a := range cons {
+		for _, j := range byCell[a.cells[0]] {
+			b := cons[j]
+			if i == j || len(b.cells) <= len(a.cells) || !isSubset(a.cells, b.cells) {
+				continue
+			}
+			changed = s.settle(difference(b.cells, a.cells), b.mines-a.mines) || changed
+		}
+	}
+	if changed {
+		return true
+	}
+
+	// 总雷数：剩下的雷数为 0 或者恰好等于所有未知格子数
+	var unknown []int
+	for i := 0; i < s.ms.Width*s.ms.Height; i++ {
+		if !s.revealed(i) && !s.safe[i] && !s.mines[i] {
+			unknown = append(unknown, i)
+		}
+	}
+	return s.settle(unknown, s.ms.MineCount-len(s.mines))
+}
+
+// settle 在 cells 中恰好有 mines 个雷且能确定所有格子时记录结论
+func (s *Solver) settle(cells []int, mines int) bool {
+	if len(cells) == 0 {
+		return false
+	}
+	var known map[int]bool
+	switch mines {
+	case 0:
+		known = s.safe
+	case len(cells):
+		known = s.mines
+	default:
+		return false
+	}
+	changed := false
+	for _, c := range cells {
+		if !known[c] {
+			known[c] = true
+			changed = true
+		}
+	}
+	return changed
+}
+
+// constraints 为每个已翻开、周围还有未知格子的数字生成一个约束
+func (s *Solver) constraints() []constraint {
+	var cons []constraint
+	for y := 0; y < s.ms.Height; y++ {
+		for x := 0; x < s.ms.Width; x++ {
+			if !s.revealed(y*s.ms.Width + x) {
+				continue
+			}
+			c := constraint{mines: s.ms.Grid[y][x].Adjacent}
+			for _, n := range s.neighbours(x, y) {
+				switch {
+				case s.mines[n]:
+					c.mines--
+				case !s.revealed(n) && !s.safe[n]:
+					c.cells = append(c.cells, n)
+				}
+			}
+			if len(c.cells) > 0 {
+				cons = append(cons, c)
+			}
+		}
+	}
+	return cons
+}
+
+func (s *Solver) revealed(i int) bool {
+	return s.ms.Grid[i/s.ms.Width][i%s.ms.Width].State == CellRevealed
+}
+
+// neighbours 按从小到大的顺序返回 (x, y) 周围格子的下标
+func (s *Solver) neighbours(x, y int) []int {
+	cells := make([]int, 0, 8)
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			nx, ny := x+dx, y+dy
+			if (dx != 0 || dy != 0) && nx >= 0 && nx < s.ms.Width && ny >= 0 && ny < s.ms.Height {
+				cells = append(cells, ny*s.ms.Width+nx)
+			}
+		}
+	}
+	return cells
+}
+
+func (s *Solver) points(cells []int) []Point {
+	sort.Ints(cells)
+	points := make([]Point, len(cells))
+	for i, c := range cells {
+		points[i] = Point{X: c % s.ms.Width, Y: c / s.ms.Width}
+	}
+	return points
+}
+
+// isSubset 报告有序切片 a 是否是有序切片 b 的子集
+func isSubset(a, b []int) bool {
+	j := 0
+	for _, x := range a {
+		for j < len(b) && b[j] < x {
+			j++
+		}
+		if j == len(b) || b[j] != x {
+			return false
+		}
+	}
+	return true
+}
+
+// difference 返回有序切片 b 中不属于 a 的元素
+func difference(b, a []int) []int {
+	var diff []int
+	j := 0
+	for _, x := range b {
+		for j < len(a) && a[j] < x {
+			j++
+		}
+		if j == len(a) || a[j] != x {
+			diff = append(diff, x)
+		}
+	}
+	return diff
+}
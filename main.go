@@ -3,39 +3,44 @@ package main
 import (
 	"context"
 	"errors"
-	"net"
+	"flag"
+	"fmt"
 	"os"
 	"os/signal"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
-	"termiplay/go-backend/game"
+	"termiplay/go-backend/config"
 	"termiplay/go-backend/models"
+	"termiplay/go-backend/player"
+	"termiplay/go-backend/registry"
+	"termiplay/go-backend/store"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/log"
 	"github.com/charmbracelet/ssh"
 	"github.com/charmbracelet/wish"
-	"github.com/charmbracelet/wish/activeterm"
 	btea "github.com/charmbracelet/wish/bubbletea"
 	"github.com/charmbracelet/wish/logging"
+	gossh "golang.org/x/crypto/ssh"
 )
 
-const (
-	host = "0.0.0.0"
-	port = "23234"
-)
-
-// appModel manages the state machine between lobby and games
+// appModel switches between the lobby and the games in the registry.
 type appModel struct {
+	session *registry.Session
 	current tea.Model
-	state   string // "lobby", "minesweeper", "game2048"
+
+	// size is the last window size reported by the terminal, passed on to
+	// every model we switch to.
+	size tea.WindowSizeMsg
 }
 
-func newAppModel() *appModel {
+func newAppModel(s *registry.Session, first tea.Model) *appModel {
 	return &appModel{
-		current: models.NewLobbyModel(),
-		state:   "lobby",
+		session: s,
+		current: first,
 	}
 }
 
@@ -53,63 +58,50 @@ func (m *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.String() == "ctrl+c" {
 			return m, tea.Quit
 		}
+	case tea.WindowSizeMsg:
+		m.size = msg
+	case registry.LaunchMsg:
+		return m, m.switchTo(msg.Game.New(m.session))
+	case registry.OpenMsg:
+		return m, m.switchTo(msg.Model)
+	case registry.ExitMsg:
+		// Leaving with q discards the game; players save explicitly with
+		// Ctrl+S, and daily challenges save themselves before exiting.
+		return m, m.switchTo(models.NewLobbyModel(m.session))
 	}
 
-	// Update current model
-	updatedModel, cmd := m.current.Update(msg)
-
-	// Check if we need to transition states
-	switch m.state {
-	case "lobby":
-		if lobbyModel, ok := updatedModel.(*models.LobbyModel); ok {
-			if lobbyModel.IsGameChosen() {
-				selected := lobbyModel.GetSelected()
-				if selected == -1 {
-					// User quit
-					return m, tea.Quit
-				}
-				switch selected {
-				case models.Minesweeper:
-					// Transition to minesweeper
-					m.current = models.NewMinesweeperModel(game.Easy)
-					m.state = "minesweeper"
-					return m, m.current.Init()
-				case models.Game2048:
-					// Transition to 2048
-					m.current = models.NewGame2048Model()
-					m.state = "game2048"
-					return m, m.current.Init()
-				}
-			}
-		}
-	case "minesweeper":
-		if minesweeperModel, ok := updatedModel.(*models.MinesweeperModel); ok {
-			// Check if user wants to quit
-			if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "q" {
-				// Return to lobby
-				m.current = models.NewLobbyModel()
-				m.state = "lobby"
-				return m, m.current.Init()
-			}
-			_ = minesweeperModel // avoid unused variable
-		}
-	case "game2048":
-		if game2048Model, ok := updatedModel.(*models.Game2048Model); ok {
-			// Check if user wants to quit
-			if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "q" {
-				// Return to lobby
-				m.current = models.NewLobbyModel()
-				m.state = "lobby"
-				return m, m.current.Init()
-			}
-			_ = game2048Model // avoid unused variable
-		}
+	prev := m.current
+	next, cmd := m.current.Update(msg)
+	if next != prev {
+		return m, tea.Batch(cmd, m.switchTo(next))
 	}
-
-	m.current = updatedModel
 	return m, cmd
 }
 
+// switchTo makes next the current model and tells it the window size, which
+// the terminal only reports once at startup and when it changes.
+func (m *appModel) switchTo(next tea.Model) tea.Cmd {
+	m.current = next
+	init := next.Init()
+	if m.size.Width == 0 {
+		return init
+	}
+	size := m.size
+	return tea.Batch(init, func() tea.Msg { return size })
+}
+
+// saveCurrent saves the game in progress, if any, when the connection drops
+// so that it can be resumed from the lobby later.
+func (m *appModel) saveCurrent() {
+	r, ok := m.current.(registry.Resumable)
+	if !ok || m.session.Player.IsGuest() {
+		return
+	}
+	if err := r.Save(); err != nil {
+		log.Error("Could not save game", "player", m.session.Player.ID, "error", err)
+	}
+}
+
 func (m *appModel) View() string {
 	if m.current != nil {
 		return m.current.View()
@@ -117,19 +109,157 @@ func (m *appModel) View() string {
 	return "Loading..."
 }
 
-// teaHandler is the function that returns our Bubble Tea program.
-func teaHandler(s ssh.Session) (tea.Model, []tea.ProgramOption) {
-	m := newAppModel()
-	return m, []tea.ProgramOption{tea.WithAltScreen()}
+// newSession identifies the player behind s and loads their saved profile.
+func newSession(s ssh.Session, st *store.Store) *registry.Session {
+	p := player.FromSession(s)
+	if !p.IsGuest() {
+		saved, ok, err := st.Player(p.ID)
+		if err != nil {
+			log.Error("Could not load player", "id", p.ID, "error", err)
+		} else if ok {
+			p = saved
+		}
+	}
+	return &registry.Session{Player: p, Store: st}
+}
+
+// appModelKey is the session context key under which teaHandler stores the
+// session's appModel.
+type appModelKey struct{}
+
+// teaHandler returns the function that builds our Bubble Tea program. The
+// SSH command, if any, picks the first screen; an unknown command ends the
+// session with an error instead.
+func teaHandler(st *store.Store) btea.Handler {
+	return func(s ssh.Session) (tea.Model, []tea.ProgramOption) {
+		sess := newSession(s, st)
+		first, err := commandModel(sess, s.Command())
+		if err != nil {
+			wish.Fatalln(s, err.Error()+"\n\n"+commandUsage())
+			return nil, nil
+		}
+		m := newAppModel(sess, first)
+		s.Context().SetValue(appModelKey{}, m)
+		return m, []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
+	}
+}
+
+// saveOnDisconnect saves the game a player was in once their Bubble Tea
+// program has stopped, whether they quit or the connection dropped.
+func saveOnDisconnect() wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			next(s)
+			if m, ok := s.Context().Value(appModelKey{}).(*appModel); ok {
+				m.saveCurrent()
+			}
+		}
+	}
+}
+
+// scripts runs the non-interactive commands such as "ssh host scores --json",
+// which print their output and exit. They work without a PTY, so this has to
+// run before requireTerminal turns such sessions away.
+func scripts(st *store.Store) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			args := s.Command()
+			if len(args) == 0 {
+				next(s)
+				return
+			}
+			script, ok := models.LookupScript(args[0])
+			if !ok {
+				next(s)
+				return
+			}
+			if err := script.Run(newSession(s, st), args[1:], s, s); err != nil {
+				wish.Fatalln(s, args[0]+": "+err.Error())
+			}
+		}
+	}
+}
+
+// requireTerminal turns away sessions without a PTY, which the Bubble Tea
+// screens need. Scripts have already run by now, so what is left is either an
+// interactive command, which gets a hint to retry with ssh -t, or an unknown
+// one, which gets the usage.
+func requireTerminal() wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			if _, _, ok := s.Pty(); ok {
+				next(s)
+				return
+			}
+			args := s.Command()
+			if len(args) > 0 && !isCommand(args[0]) {
+				wish.Fatalln(s, fmt.Sprintf("未知的命令 %q\n\n%s", args[0], commandUsage()))
+				return
+			}
+			wish.Fatalln(s, strings.TrimSpace("需要终端，请加上 -t 重新连接: ssh -t host "+strings.Join(args, " ")))
+		}
+	}
+}
+
+// limitSessions turns away new connections while limit sessions are open. A
+// limit of 0 means no limit.
+func limitSessions(limit int) wish.Middleware {
+	var open atomic.Int64
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			if limit == 0 {
+				next(s)
+				return
+			}
+			defer open.Add(-1)
+			if open.Add(1) > int64(limit) {
+				log.Warn("Too many sessions", "limit", limit, "remote", s.RemoteAddr())
+				wish.Fatalln(s, "服务器已满，请稍后再试")
+				return
+			}
+			next(s)
+		}
+	}
 }
 
 func main() {
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid configuration:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	log.SetLevel(cfg.Level())
+	// Games register themselves when the models package is loaded; the
+	// configuration picks which of them the lobby lists, and in what order.
+	if err := registry.Enable(cfg.Games, cfg.Configure); err != nil {
+		log.Error("Could not enable games", "error", err)
+		os.Exit(2)
+	}
+
+	st, err := store.Open(cfg.DataDir)
+	if err != nil {
+		log.Error("Could not open data directory", "dir", cfg.DataDir, "error", err)
+		os.Exit(1)
+	}
+
 	s, err := wish.NewServer(
-		wish.WithAddress(net.JoinHostPort(host, port)),
-		wish.WithHostKeyPath(".ssh/termiplay_ed25519"),
+		wish.WithAddress(cfg.Address()),
+		wish.WithHostKeyPath(cfg.HostKeyPath),
+		wish.WithIdleTimeout(cfg.IdleTimeout),
+		// Any public key is accepted; its fingerprint identifies the player.
+		wish.WithPublicKeyAuth(func(ssh.Context, ssh.PublicKey) bool { return true }),
+		// Clients without a key can still play as a guest.
+		wish.WithKeyboardInteractiveAuth(func(ssh.Context, gossh.KeyboardInteractiveChallenge) bool { return true }),
 		wish.WithMiddleware(
-			btea.Middleware(teaHandler),
-			activeterm.Middleware(), // Bubble Tea apps usually require a PTY.
+			btea.Middleware(teaHandler(st)),
+			saveOnDisconnect(),
+			requireTerminal(),
+			scripts(st),
+			limitSessions(cfg.MaxSessions),
 			logging.Middleware(),
 		),
 	)
@@ -140,7 +270,7 @@ func main() {
 
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-	log.Info("Starting SSH server", "host", host, "port", port)
+	log.Info("Starting SSH server", "address", cfg.Address(), "games", cfg.Games)
 
 	go func() {
 		if err = s.ListenAndServe(); err != nil && !errors.Is(err, ssh.ErrServerClosed) {
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"termiplay/go-backend/registry"
+	"termiplay/go-backend/store"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+var doneStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("46"))
+
+// DailyModel 是每日挑战的菜单，列出支持每日挑战的游戏和当天的排行榜
+type DailyModel struct {
+	session *registry.Session
+	day     string
+	choices []lobbyItem
+	done    map[string]bool // 玩家今天已经完成的游戏
+	started map[string]bool // 玩家今天开始了但还没有完成的游戏
+	cursor  int
+	err     string
+}
+
+func NewDailyModel(s *registry.Session, day string) *DailyModel {
+	m := &DailyModel{
+		session: s,
+		day:     day,
+		done:    make(map[string]bool),
+		started: make(map[string]bool),
+	}
+
+	for _, g := range registry.All() {
+		dg, ok := g.(registry.DailyGame)
+		if !ok {
+			continue
+		}
+		m.choices = append(m.choices, lobbyItem{
+			id:   dg.ID(),
+			name: dg.Name(),
+			cmd: func() tea.Msg {
+				return openDaily(s, dg, day)
+			},
+		})
+	}
+	m.choices = append(m.choices, lobbyItem{
+		name: "🏆 今日排行榜",
+		cmd: func() tea.Msg {
+			return registry.OpenMsg{Model: NewDailyLeaderboardModel(s, day)}
+		},
+	})
+
+	results, _ := s.Store.Results(func(r store.Result) bool {
+		return r.PlayerID == s.Player.ID && r.Daily == day
+	})
+	for _, r := range results {
+		m.done[r.Game] = true
+	}
+	for _, sv := range dailySaves(s, day) {
+		m.started[sv.Game] = true
+	}
+	return m
+}
+
+// dailySaves 返回玩家在日期 day 的每日挑战中还没有完成的存档
+func dailySaves(s *registry.Session, day string) []store.Save {
+	if s.Player.IsGuest() {
+		return nil
+	}
+	saves, err := s.Store.Saves(s.Player.ID)
+	if err != nil {
+		log.Error("Could not load saves", "player", s.Player.ID, "error", err)
+		return nil
+	}
+	return slices.DeleteFunc(saves, func(sv store.Save) bool { return sv.Daily != day })
+}
+
+// openDaily 打开每日挑战。离开时每日挑战总是会保存，有存档就从存档继续，
+// 这样玩家不能中途退出，再凭着已经看过的棋局重新开始
+func openDaily(s *registry.Session, dg registry.DailyGame, day string) tea.Msg {
+	rg, ok := dg.(registry.ResumableGame)
+	if !ok {
+		return registry.OpenMsg{Model: dg.NewDaily(s, day)}
+	}
+	for _, sv := range dailySaves(s, day) {
+		if sv.Game != dg.ID() {
+			continue
+		}
+		m, err := rg.Resume(s, sv.Data)
+		if err != nil {
+			return lobbyErrorMsg{err}
+		}
+		return registry.OpenMsg{Model: m}
+	}
+	return registry.OpenMsg{Model: dg.NewDaily(s, day)}
+}
+
+func (m *DailyModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *DailyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case lobbyErrorMsg:
+		m.err = "无法打开: " + msg.err.Error()
+	case tea.KeyMsg:
+		m.err = ""
+		switch msg.String() {
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.choices)-1 {
+				m.cursor++
+			}
+		case "enter", " ":
+			return m, m.choices[m.cursor].cmd
+		case "q", "esc":
+			return m, registry.Exit
+		}
+	}
+	return m, nil
+}
+
+func (m *DailyModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("📅 每日挑战 " + m.day))
+	b.WriteString("\n\n")
+	b.WriteString("今天所有玩家的棋局都相同，只有第一次完成的成绩计入排行榜。\n中途离开时会保存进度，再次进入时从存档继续。\n\n")
+
+	for i, choice := range m.choices {
+		cursor := " "
+		style := menuItemStyle
+		if m.cursor == i {
+			cursor = ">"
+			style = selectedStyle
+		}
+
+		status := ""
+		if choice.id != "" {
+			switch {
+			case m.done[choice.id]:
+				status = doneStyle.Render("✓ 已完成")
+			case m.started[choice.id]:
+				status = descriptionStyle.Render("进行中")
+			default:
+				status = descriptionStyle.Render("未完成")
+			}
+		}
+		b.WriteString(fmt.Sprintf("%s %s %s\n", cursor, style.Render(choice.name), status))
+	}
+
+	if m.err != "" {
+		b.WriteString("\n" + errorStyle.Render(m.err) + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(lobbyHelpStyle.Render("↑/↓ 选择 | Enter 开始 | Q 返回大厅"))
+
+	return b.String()
+}
@@ -0,0 +1,233 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"termiplay/go-backend/game"
+	"termiplay/go-backend/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// minesweeperChrome 是扫雷界面中雷区以外占用的行数
+const minesweeperChrome = 8
+
+// difficultyChoices 是难度选择界面中的选项，最后一项是自定义
+var difficultyChoices = []game.Difficulty{game.Easy, game.Medium, game.Hard, game.Custom}
+
+// customFields 是自定义表单中各项的名称
+var customFields = []string{"宽度", "高度", "雷数"}
+
+// DifficultyModel 让玩家在开始扫雷之前选择难度
+type DifficultyModel struct {
+	session *registry.Session
+	game    MinesweeperGame
+	cursor  int
+	noGuess bool
+	err     string
+
+	// 由命令行参数直接开局时为 true，等知道终端大小、检查过雷区放得下再开始
+	pending bool
+
+	// 终端大小，还没有收到时为 0
+	width  int
+	height int
+
+	// 自定义表单的状态
+	custom bool
+	field  int
+	values [3][]rune
+}
+
+func NewDifficultyModel(s *registry.Session, g MinesweeperGame) *DifficultyModel {
+	m := &DifficultyModel{
+		session: s,
+		game:    g,
+		noGuess: g.NoGuess,
+		cursor:  max(slices.Index(difficultyChoices, g.Difficulty), 0),
+	}
+	cfg := game.Medium.Config()
+	for i, v := range []int{cfg.Width, cfg.Height, cfg.Mines} {
+		m.values[i] = []rune(strconv.Itoa(v))
+	}
+	return m
+}
+
+func (m *DifficultyModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *DifficultyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		if m.pending {
+			m.pending = false
+			d := difficultyChoices[m.cursor]
+			return m.start(d, d.Config())
+		}
+	case tea.KeyMsg:
+		if m.custom {
+			return m.updateCustom(msg)
+		}
+		m.err = ""
+		switch msg.String() {
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(difficultyChoices)-1 {
+				m.cursor++
+			}
+		case "g":
+			m.noGuess = !m.noGuess
+		case "enter", " ":
+			d := difficultyChoices[m.cursor]
+			if d == game.Custom {
+				m.custom = true
+				return m, nil
+			}
+			return m.start(d, d.Config())
+		case "q", "esc":
+			return m, registry.Exit
+		}
+	}
+	return m, nil
+}
+
+// updateCustom 处理自定义表单中的按键
+func (m *DifficultyModel) updateCustom(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.err = ""
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.custom = false
+	case tea.KeyUp, tea.KeyShiftTab:
+		m.field = (m.field + len(customFields) - 1) % len(customFields)
+	case tea.KeyDown, tea.KeyTab:
+		m.field = (m.field + 1) % len(customFields)
+	case tea.KeyBackspace:
+		if v := m.values[m.field]; len(v) > 0 {
+			m.values[m.field] = v[:len(v)-1]
+		}
+	case tea.KeyEnter:
+		cfg, err := m.customConfig()
+		if err != nil {
+			m.err = err.Error()
+			return m, nil
+		}
+		return m.start(game.Custom, cfg)
+	case tea.KeyRunes:
+		for _, r := range msg.Runes {
+			if r >= '0' && r <= '9' && len(m.values[m.field]) < 4 {
+				m.values[m.field] = append(m.values[m.field], r)
+			}
+		}
+	}
+	return m, nil
+}
+
+// customConfig 读取自定义表单并检查设置是否可用
+func (m *DifficultyModel) customConfig() (game.MinesweeperConfig, error) {
+	var v [3]int
+	for i, s := range m.values {
+		n, err := strconv.Atoi(string(s))
+		if err != nil {
+			return game.MinesweeperConfig{}, fmt.Errorf("请输入%s", customFields[i])
+		}
+		v[i] = n
+	}
+	cfg := game.MinesweeperConfig{Width: v[0], Height: v[1], Mines: v[2]}
+	return cfg, cfg.Validate(m.game.FirstClick)
+}
+
+// start 检查雷区能否放进终端，然后开始游戏
+func (m *DifficultyModel) start(d game.Difficulty, cfg game.MinesweeperConfig) (tea.Model, tea.Cmd) {
+	if err := m.fits(cfg); err != nil {
+		m.err = err.Error()
+		return m, nil
+	}
+	g := m.game
+	g.NoGuess = m.noGuess
+	return g.newModel(m.session, d, cfg, ""), nil
+}
+
+// fits 检查雷区能否完整地显示在终端中，每个格子占 3 列，边框占 2 列
+func (m *DifficultyModel) fits(cfg game.MinesweeperConfig) error {
+	if m.width > 0 && cfg.Width*3+2 > m.width {
+		return fmt.Errorf("终端太窄，当前最多放下 %d 列", (m.width-2)/3)
+	}
+	if m.height > 0 && cfg.Height+minesweeperChrome > m.height {
+		return fmt.Errorf("终端太矮，当前最多放下 %d 行", m.height-minesweeperChrome)
+	}
+	return nil
+}
+
+func (m *DifficultyModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("💣 选择难度"))
+	b.WriteString("\n\n")
+
+	if m.custom {
+		m.viewCustom(&b)
+	} else {
+		m.viewChoices(&b)
+	}
+
+	if m.err != "" {
+		b.WriteString("\n")
+		b.WriteString(errorStyle.Render(m.err))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (m *DifficultyModel) viewChoices(b *strings.Builder) {
+	for i, d := range difficultyChoices {
+		cursor := " "
+		style := menuItemStyle
+		if m.cursor == i {
+			cursor = ">"
+			style = selectedStyle
+		}
+
+		desc := "自己设定宽度、高度和雷数"
+		if d != game.Custom {
+			cfg := d.Config()
+			desc = fmt.Sprintf("%d×%d，%d 个雷", cfg.Width, cfg.Height, cfg.Mines)
+		}
+		b.WriteString(fmt.Sprintf("%s %s\n", cursor, style.Render(difficultyNames[d])))
+		b.WriteString(fmt.Sprintf("    %s\n\n", descriptionStyle.Render(desc)))
+	}
+
+	noGuess := "关"
+	if m.noGuess {
+		noGuess = "开"
+	}
+	b.WriteString(fmt.Sprintf("无需猜测: %s\n", playerStyle.Render(noGuess)))
+	b.WriteString(lobbyHelpStyle.Render("↑/↓ 选择 | Enter 开始 | G 切换无需猜测 | Q 返回大厅"))
+}
+
+func (m *DifficultyModel) viewCustom(b *strings.Builder) {
+	for i, name := range customFields {
+		cursor := " "
+		style := menuItemStyle
+		value := string(m.values[i])
+		if m.field == i {
+			cursor = ">"
+			style = selectedStyle
+			value += "_"
+		}
+		b.WriteString(fmt.Sprintf("%s %s %s\n", cursor, style.Render(name+":"), inputStyle.Render(value)))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(descriptionStyle.Render(fmt.Sprintf("宽度 %d-%d，高度 %d-%d",
+		game.MinBoardSize, game.MaxBoardWidth, game.MinBoardSize, game.MaxBoardHeight)))
+	b.WriteString("\n")
+	b.WriteString(lobbyHelpStyle.Render("↑/↓ 切换 | 数字输入 | Enter 开始 | Esc 返回"))
+}
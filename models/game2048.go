@@ -1,13 +1,22 @@
 package models
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"termiplay/go-backend/config"
 	"termiplay/go-backend/game"
+	"termiplay/go-backend/registry"
+	"termiplay/go-backend/store"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
 )
 
 var (
@@ -36,6 +45,11 @@ var (
 			MarginBottom(1).
 			Align(lipgloss.Center)
 
+	decisionStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("46")).
+			Padding(0, 2)
+
 	gameOverStyle = lipgloss.NewStyle().
 			Bold(true).
 			Foreground(lipgloss.Color("196")).
@@ -61,6 +75,10 @@ func getCellColor(value int) lipgloss.Color {
 	if color, ok := colors[value]; ok {
 		return color
 	}
+	// 更大的目标拼出的方块
+	if value > 2048 {
+		return lipgloss.Color("129")
+	}
 	return lipgloss.Color("235")
 }
 
@@ -71,14 +89,229 @@ func getTextColor(value int) lipgloss.Color {
 	return lipgloss.Color("0")
 }
 
-type Game2048Model struct {
-	game *game.Game2048
+// Game2048Game 是 2048 在注册表中的描述。
+type Game2048Game struct {
+	// Config 是设置界面中默认的棋盘大小和目标，为零值时使用 game.Default2048Config
+	Config game.Game2048Config
+}
+
+func init() {
+	registry.Register(Game2048Game{}, 20)
+}
+
+func (Game2048Game) ID() string          { return "2048" }
+func (Game2048Game) Name() string        { return "2048" }
+func (Game2048Game) Description() string { return "合并数字方块，拼出 2048" }
+
+// Configure 使用配置中默认的棋盘设置
+func (g Game2048Game) Configure(c config.Config) registry.Game {
+	g.Config = c.Game2048()
+	return g
+}
+
+// New 先让玩家选择模式
+func (g Game2048Game) New(s *registry.Session) tea.Model {
+	cfg := g.Config
+	if cfg == (game.Game2048Config{}) {
+		cfg = game.Default2048Config
+	}
+	return NewGame2048SetupModel(s, cfg)
+}
+
+func (Game2048Game) Usage() string {
+	return "[--size N] [--target N] [--practice]"
+}
+
+// NewWithArgs 跳过设置界面，直接按参数开始一局
+func (g Game2048Game) NewWithArgs(s *registry.Session, args []string) (tea.Model, error) {
+	cfg := g.Config
+	if cfg == (game.Game2048Config{}) {
+		cfg = game.Default2048Config
+	}
+	mode := rankedMode
+	for i := 0; i < len(args); i++ {
+		switch a := args[i]; a {
+		case "--practice":
+			mode = practiceMode
+		case "--size", "--target":
+			if i+1 == len(args) {
+				return nil, fmt.Errorf("%s 后面需要一个数字", a)
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil {
+				return nil, fmt.Errorf("%s 后面需要一个数字，而不是 %q", a, args[i])
+			}
+			if a == "--size" {
+				cfg.Size = n
+			} else {
+				cfg.Target = n
+			}
+		default:
+			return nil, fmt.Errorf("未知的参数 %q", a)
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(game.Game2048Targets, cfg.Target) {
+		return nil, fmt.Errorf("目标必须是 %d 到 %d 之间的 2 的幂",
+			game.Game2048Targets[0], game.Game2048Targets[len(game.Game2048Targets)-1])
+	}
+	return NewGame2048Model(s, mode, cfg), nil
 }
 
-func NewGame2048Model() *Game2048Model {
+// NewDaily 开始每日挑战，每日挑战总是排位模式
+func (Game2048Game) NewDaily(s *registry.Session, day string) tea.Model {
+	m := NewGame2048Model(s, rankedMode, game.Default2048Config)
+	m.daily = day
+	m.game = m.newGame()
+	return m
+}
+
+func (Game2048Game) Resume(s *registry.Session, data []byte) (tea.Model, error) {
+	var sv game2048Save
+	if err := json.Unmarshal(data, &sv); err != nil {
+		return nil, err
+	}
+	if sv.Game == nil {
+		return nil, errors.New("存档中没有对局")
+	}
+	// 离线的时间不计入用时
+	sv.Game.StartTime = time.Now().Add(-sv.Elapsed)
 	return &Game2048Model{
-		game: game.NewGame2048(),
+		session: s,
+		game:    sv.Game,
+		config:  sv.Game.Config(),
+		mode:    sv.Mode,
+		daily:   sv.Daily,
+		hints:   sv.Hints,
+	}, nil
+}
+
+func (Game2048Game) NewPlayback(data []byte) (registry.Playback, error) {
+	var r game2048Replay
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
 	}
+
+	var m *Game2048Model
+	p := &actionPlayback{
+		actions: r.Actions,
+		reset: func() {
+			m = &Game2048Model{mode: r.Mode, config: r.Config}
+			m.game = game.NewCustomGame2048(r.Config, r.Seed)
+			m.game.HistoryLimit = m.mode.historyLimit()
+		},
+		apply: func(a game.Action) { m.game.Apply(a) },
+		view:  func() string { return m.render() },
+	}
+	p.Rewind()
+	return p, nil
+}
+
+// game2048Replay 是 2048 录像的内容
+type game2048Replay struct {
+	Seed    int64
+	Config  game.Game2048Config
+	Mode    game2048Mode
+	Actions []game.Action
+}
+
+// game2048Save 是 2048 存档的内容
+type game2048Save struct {
+	Game    *game.Game2048
+	Mode    game2048Mode
+	Daily   string
+	Elapsed time.Duration
+	Hints   int
+}
+
+// game2048Variant 返回记录成绩时使用的变体。经典的 4x4 棋盘沿用空变体，
+// 和加入棋盘设置之前的成绩放在同一个榜单
+func game2048Variant(cfg game.Game2048Config) string {
+	if cfg == game.Default2048Config {
+		return ""
+	}
+	return cfg.String()
+}
+
+// game2048CellStyleFor 按棋盘边长缩小格子，让大棋盘也能放进终端
+func game2048CellStyleFor(size int) lipgloss.Style {
+	switch {
+	case size <= 4:
+		return game2048CellStyle
+	case size <= 6:
+		return game2048CellStyle.Copy().Width(8).Height(1)
+	default:
+		return game2048CellStyle.Copy().Width(6).Height(1)
+	}
+}
+
+// game2048Mode 是 2048 的对局模式，决定能撤销多少次
+type game2048Mode int
+
+const (
+	rankedMode   game2048Mode = iota // 排位模式，不能撤销
+	practiceMode                     // 练习模式，可以无限撤销
+)
+
+func (mode game2048Mode) String() string {
+	if mode == practiceMode {
+		return "练习模式"
+	}
+	return "排位模式"
+}
+
+// undoBudget 返回一局中可以撤销的次数，-1 表示不限
+func (mode game2048Mode) undoBudget() int {
+	if mode == practiceMode {
+		return -1
+	}
+	return 0
+}
+
+// historyLimit 返回引擎需要保存的历史局面数
+func (mode game2048Mode) historyLimit() int {
+	if mode.undoBudget() == 0 {
+		return 0
+	}
+	return game.MaxUndoHistory
+}
+
+type Game2048Model struct {
+	session  *registry.Session
+	game     *game.Game2048
+	config   game.Game2048Config
+	mode     game2048Mode
+	recorded bool
+	daily    string // 每日挑战的日期，普通对局为空
+	status   string // 显示在信息栏中的提示，例如保存结果
+	replayID string // 本局录像的编号
+
+	// 拼出目标后的对话框中是否选中了“结束”
+	finishing bool
+
+	// 正在播放的移动动画，没有动画时为空
+	anim   *game2048Anim
+	tickID int
+
+	// AI 提示和自动游玩，用过的对局不计入排行榜
+	hint      string // 最近一次提示的方向
+	hints     int    // 使用提示和自动游玩走的步数
+	autoplay  bool
+	autoSpeed int // autoplaySpeeds 中的下标
+	aiID      int
+}
+
+func NewGame2048Model(s *registry.Session, mode game2048Mode, cfg game.Game2048Config) *Game2048Model {
+	m := &Game2048Model{
+		session: s,
+		config:  cfg,
+		mode:    mode,
+	}
+	m.game = m.newGame()
+	return m
 }
 
 func (m *Game2048Model) Init() tea.Cmd {
@@ -86,81 +319,361 @@ func (m *Game2048Model) Init() tea.Cmd {
 }
 
 func (m *Game2048Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
 	switch msg := msg.(type) {
+	case game2048FrameMsg:
+		return m, m.nextFrame(msg)
+	case game2048AIMsg:
+		return m, m.updateAI(msg)
 	case tea.KeyMsg:
-		if m.game.GameOver {
+		// 新的按键直接跳到动画的最后一帧
+		m.stopAnimation()
+		m.status = ""
+		m.hint = ""
+		key := msg.String()
+		// 自动游玩时除了调整速度以外的按键都会让它停下
+		if m.autoplay && key != "p" && key != "+" && key != "=" && key != "-" {
+			m.stopAI()
+		}
+		switch {
+		case m.game.GameOver:
 			switch msg.String() {
+			case "u", "ctrl+z":
+				// 练习模式可以撤销导致无路可走的那一步
+				if m.canUndo() {
+					m.undo()
+				}
 			case "r":
-				m.game.Reset()
-			case "q", "ctrl+c":
-				return m, tea.Quit
+				m.reset()
+			case "q":
+				return m, m.leave()
 			}
-		} else {
+		case m.deciding():
+			if msg.String() == "q" {
+				return m, m.leave()
+			}
+			m.updateDecision(msg)
+		default:
 			switch msg.String() {
 			case "up", "k", "w":
-				m.game.Move("up")
+				cmd = m.move("up")
 			case "down", "j", "s":
-				m.game.Move("down")
+				cmd = m.move("down")
 			case "left", "h", "a":
-				m.game.Move("left")
+				cmd = m.move("left")
 			case "right", "l", "d":
-				m.game.Move("right")
+				cmd = m.move("right")
+			case "u", "ctrl+z":
+				m.undo()
+			case "t":
+				cmd = m.think(0)
+			case "p":
+				if m.autoplay {
+					m.stopAI()
+				} else {
+					m.autoplay = true
+					cmd = m.think(0)
+				}
+			case "+", "=":
+				m.autoSpeed = min(m.autoSpeed+1, len(autoplaySpeeds)-1)
+			case "-":
+				m.autoSpeed = max(m.autoSpeed-1, 0)
 			case "r":
-				m.game.Reset()
-			case "q", "ctrl+c":
-				return m, tea.Quit
+				// 每日挑战只有一次机会，结束之前不能重新开始
+				if m.daily == "" {
+					m.reset()
+				}
+			case "ctrl+s":
+				m.status = saveStatus(m.save())
+			case "q":
+				return m, m.leave()
 			}
+			m.finish()
+		}
+	}
+	return m, cmd
+}
+
+// deciding 报告是否正在等玩家决定拼出目标后是否继续
+func (m *Game2048Model) deciding() bool {
+	return m.game.Deciding()
+}
+
+// updateDecision 处理拼出目标后的对话框中的按键
+func (m *Game2048Model) updateDecision(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "up", "down", "left", "right", "k", "j", "h", "l", "tab":
+		m.finishing = !m.finishing
+	case "c":
+		m.game.Continue()
+	case "f":
+		m.game.Finish()
+	case "enter", " ":
+		if m.finishing {
+			m.game.Finish()
+		} else {
+			m.game.Continue()
+		}
+	}
+	m.finish()
+}
+
+// move 向 direction 移动，棋盘发生变化时丢弃为旧棋盘搜索的提示并开始播放动画
+func (m *Game2048Model) move(direction string) tea.Cmd {
+	r := m.game.Move(direction)
+	if r.Moved {
+		m.aiID++
+	}
+	if !r.Moved || m.session.Player.Settings.ReduceMotion {
+		return nil
+	}
+	return m.startAnimation(r)
+}
+
+// canUndo 报告模式和局面是否都允许撤销
+func (m *Game2048Model) canUndo() bool {
+	budget := m.mode.undoBudget()
+	return (budget < 0 || m.game.Undos < budget) && m.game.CanUndo()
+}
+
+// undo 在模式允许时撤销上一步
+func (m *Game2048Model) undo() {
+	if budget := m.mode.undoBudget(); budget >= 0 && m.game.Undos >= budget {
+		m.status = m.mode.String() + "不能撤销"
+		return
+	}
+	if !m.game.Undo() {
+		m.status = "没有可以撤销的移动"
+		return
+	}
+	m.aiID++
+}
+
+func (m *Game2048Model) reset() {
+	m.record()
+	m.game.Reset(m.seed())
+	m.stopAI()
+	m.hints = 0
+	m.finishing = false
+	m.recorded = false
+	m.replayID = ""
+}
+
+// newGame 按当前模式开始新的一局
+func (m *Game2048Model) newGame() *game.Game2048 {
+	g := game.NewCustomGame2048(m.config, m.seed())
+	g.HistoryLimit = m.mode.historyLimit()
+	return g
+}
+
+// seed 返回新一局使用的种子，每日挑战总是使用当天的种子
+func (m *Game2048Model) seed() int64 {
+	if m.daily != "" {
+		return game.DailySeed(m.daily)
+	}
+	return game.NewSeed()
+}
+
+// Save 保存进行中的对局，还没有得分时不保存，但每日挑战一开始就算用掉了机会，总是保存。
+// 已经结束、还在等玩家决定是否撤销的对局在断开连接时记录成绩
+func (m *Game2048Model) Save() error {
+	if m.game.GameOver {
+		m.record()
+		return nil
+	}
+	if m.game.Score == 0 && m.daily == "" {
+		return nil
+	}
+	return m.save()
+}
+
+// leave 返回大厅。每日挑战离开时总是保存，再次进入时从存档继续，
+// 不能凭着已经看过的棋局重新开始
+func (m *Game2048Model) leave() tea.Cmd {
+	m.record()
+	if m.daily != "" && !m.session.Player.IsGuest() {
+		if err := m.Save(); err != nil {
+			log.Error("Could not save daily game", "player", m.session.Player.ID, "error", err)
 		}
 	}
-	return m, nil
+	return registry.Exit
+}
+
+func (m *Game2048Model) save() error {
+	return saveGame(m.session, Game2048Game{}.ID(), m.daily, game2048Save{
+		Game:    m.game,
+		Mode:    m.mode,
+		Daily:   m.daily,
+		Elapsed: time.Since(m.game.StartTime),
+		Hints:   m.hints,
+	})
+}
+
+// finish 在游戏刚结束时记录成绩。还能撤销时先不记录，玩家可能撤销最后一步
+// 接着玩，等离开或重新开始时再记录
+func (m *Game2048Model) finish() {
+	if m.game.GameOver && m.canUndo() {
+		return
+	}
+	m.record()
+}
+
+// record 保存已经结束的对局的分数并删除存档，每局只记录一次
+func (m *Game2048Model) record() {
+	if !m.game.GameOver || m.recorded {
+		return
+	}
+	m.recorded = true
+	deleteSave(m.session, Game2048Game{}.ID(), m.daily)
+	m.replayID = recordResult(m.session, store.Result{
+		Game:      Game2048Game{}.ID(),
+		Variant:   game2048Variant(m.config),
+		Score:     m.game.Score,
+		Duration:  time.Since(m.game.StartTime),
+		Won:       m.game.Won,
+		Seed:      m.game.Seed,
+		Daily:     m.daily,
+		Practice:  m.mode == practiceMode,
+		Undos:     m.game.Undos,
+		Continued: m.game.Continued,
+		Hints:     m.hints,
+	}, game2048Replay{
+		Seed:    m.game.Seed,
+		Config:  m.config,
+		Mode:    m.mode,
+		Actions: m.game.Actions,
+	})
 }
 
 func (m *Game2048Model) View() string {
+	// 帮助信息
+	var help string
+	switch {
+	case m.game.GameOver && m.canUndo():
+		help = "U 撤销 | R 重新开始 | Q 返回大厅"
+	case m.game.GameOver:
+		help = "R 重新开始 | Q 返回大厅"
+	case m.deciding():
+		help = "←/→ 选择 | Enter 确认 | C 继续 | F 结束 | Q 返回大厅"
+	case m.autoplay:
+		help = "P 停止自动 | +/- 调整速度 | 其他按键停止自动"
+	case m.daily != "":
+		help = "方向键移动 | T 提示 | P 自动 | Ctrl+S 保存 | Q 返回大厅"
+	case m.mode.undoBudget() != 0:
+		help = "方向键移动 | U 撤销 | T 提示 | P 自动 | R 重新开始 | Ctrl+S 保存 | Q 返回大厅"
+	default:
+		help = "方向键移动 | T 提示 | P 自动 | R 重新开始 | Ctrl+S 保存 | Q 返回大厅"
+	}
+	return m.render() + game2048HelpStyle.Render(help)
+}
+
+// render 渲染当前局面，不包含按键帮助，回放时也使用它
+func (m *Game2048Model) render() string {
 	var b strings.Builder
 
 	// 游戏信息
-	info := fmt.Sprintf("分数: %d", m.game.Score)
-	if m.game.Won && !m.game.GameOver {
-		info += " | 🎉 达成2048！"
+	info := fmt.Sprintf("分数: %d | 种子: %d", m.game.Score, m.game.Seed)
+	if m.game.Config() != game.Default2048Config {
+		info = fmt.Sprintf("%d×%d 目标 %d | ", m.game.Size, m.game.Size, m.game.Target) + info
+	}
+	if m.daily != "" {
+		info = "📅 每日挑战 " + m.daily + " | " + info
+	}
+	if m.mode == practiceMode {
+		info += fmt.Sprintf(" | %s | 撤销: %d", m.mode, m.game.Undos)
+	}
+	if m.game.Won && !m.game.GameOver && m.game.Continued {
+		info += fmt.Sprintf(" | 🎉 已达成%d，继续挑战", m.game.Target)
+	}
+	if m.hints > 0 {
+		info += fmt.Sprintf(" | 提示: %d", m.hints)
+	}
+	if m.autoplay {
+		info += fmt.Sprintf(" | 🤖 自动 %v/步", autoplaySpeeds[m.autoSpeed])
+	}
+	if m.status != "" {
+		info += " | " + m.status
+	}
+	if m.replayID != "" {
+		info += " | 录像: " + m.replayID
 	}
 	b.WriteString(game2048InfoStyle.Render(info))
 	b.WriteString("\n\n")
 
-	if m.game.GameOver {
-		if m.game.Won {
-			b.WriteString(winStyle.Render("🎉 恭喜！你达成了2048！ 🎉"))
+	if m.hint != "" {
+		b.WriteString(hintStyle.Render("💡 建议: " + directionNames[m.hint]))
+		b.WriteString("\n\n")
+	}
+
+	switch {
+	case m.game.GameOver && m.game.Won && m.game.Continued:
+		b.WriteString(winStyle.Render(fmt.Sprintf("游戏结束！你达成了%d，最终得分 %d", m.game.Target, m.game.Score)))
+		b.WriteString("\n\n")
+	case m.game.GameOver && m.game.Won:
+		b.WriteString(winStyle.Render(fmt.Sprintf("🎉 恭喜！你达成了%d！ 🎉", m.game.Target)))
+		b.WriteString("\n\n")
+	case m.game.GameOver:
+		b.WriteString(gameOverStyle.Render("游戏结束！无法继续移动"))
+		b.WriteString("\n\n")
+	case m.deciding():
+		b.WriteString(m.renderDecision())
+		b.WriteString("\n\n")
+	}
+
+	if m.anim != nil {
+		b.WriteString(m.renderGrid(m.anim.grid(m.game), m.anim.style))
+	} else {
+		b.WriteString(m.renderGrid(m.game.Grid, nil))
+	}
+	b.WriteString("\n\n")
+
+	return b.String()
+}
+
+// renderDecision 渲染拼出目标后询问是否继续的对话框
+func (m *Game2048Model) renderDecision() string {
+	options := []string{"继续游戏 (C)", "结束并提交成绩 (F)"}
+	selected := 0
+	if m.finishing {
+		selected = 1
+	}
+	for i, o := range options {
+		if i == selected {
+			options[i] = selectedStyle.Render("> " + o)
 		} else {
-			b.WriteString(gameOverStyle.Render("游戏结束！无法继续移动"))
+			options[i] = menuItemStyle.Render("  " + o)
 		}
-		b.WriteString("\n\n")
 	}
+	title := winStyle.Render(fmt.Sprintf("🎉 恭喜！你达成了%d！ 🎉", m.game.Target))
+	return decisionStyle.Render(lipgloss.JoinVertical(lipgloss.Left,
+		title, lipgloss.JoinHorizontal(lipgloss.Top, options...)))
+}
 
-	// 游戏网格 - 使用lipgloss的JoinHorizontal来确保正确的布局
-	gridRows := make([]string, 4)
-	for y := 0; y < 4; y++ {
-		cells := make([]string, 4)
-		for x := 0; x < 4; x++ {
-			value := m.game.Grid[y][x]
+// renderGrid 渲染棋盘，style 不为空时可以改变个别格子的样式
+func (m *Game2048Model) renderGrid(grid [][]int, style func(p game.Point, s lipgloss.Style) lipgloss.Style) string {
+	// 使用lipgloss的JoinHorizontal来确保正确的布局
+	cellStyle := game2048CellStyleFor(m.game.Size)
+	gridRows := make([]string, m.game.Size)
+	for y := 0; y < m.game.Size; y++ {
+		cells := make([]string, m.game.Size)
+		for x := 0; x < m.game.Size; x++ {
+			value := grid[y][x]
 			cellStr := " "
 			if value != 0 {
 				cellStr = fmt.Sprintf("%d", value)
 			}
 
-			style := game2048CellStyle.Copy().
+			s := cellStyle.Copy().
 				Background(getCellColor(value)).
 				Foreground(getTextColor(value))
+			if style != nil {
+				s = style(game.Point{X: x, Y: y}, s)
+			}
 
-			cells[x] = style.Render(cellStr)
+			cells[x] = s.Render(cellStr)
 		}
 		gridRows[y] = lipgloss.JoinHorizontal(lipgloss.Left, cells...)
 	}
 
-	b.WriteString(gridStyle.Render(lipgloss.JoinVertical(lipgloss.Top, gridRows...)))
-	b.WriteString("\n\n")
-
-	// 帮助信息
-	help := "方向键移动 | R 重新开始 | Q 返回大厅"
-	b.WriteString(game2048HelpStyle.Render(help))
-
-	return b.String()
+	return gridStyle.Render(lipgloss.JoinVertical(lipgloss.Top, gridRows...))
 }
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"termiplay/go-backend/ai"
+	"termiplay/go-backend/game"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// autoplaySpeeds 是自动游玩时每步之间的间隔
+var autoplaySpeeds = []time.Duration{
+	time.Second,
+	500 * time.Millisecond,
+	250 * time.Millisecond,
+	100 * time.Millisecond,
+	30 * time.Millisecond,
+}
+
+var directionNames = map[string]string{
+	"up":    "↑ 向上",
+	"down":  "↓ 向下",
+	"left":  "← 向左",
+	"right": "→ 向右",
+}
+
+var hintStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("226")).
+	Bold(true)
+
+// game2048AIMsg 是后台搜索的结果，id 用来丢弃局面已经改变之后才算完的结果。
+// 移动、撤销和重新开始都会让 aiID 增加
+type game2048AIMsg struct {
+	id  int
+	dir string
+	ok  bool
+}
+
+// think 在 delay 之后于后台为当前局面搜索最佳方向
+func (m *Game2048Model) think(delay time.Duration) tea.Cmd {
+	m.aiID++
+	id := m.aiID
+	grid := game.CloneGrid(m.game.Grid)
+	search := func() tea.Msg {
+		dir, ok := ai.BestMove(grid)
+		return game2048AIMsg{id: id, dir: dir, ok: ok}
+	}
+	if delay == 0 {
+		return search
+	}
+	return tea.Tick(delay, func(time.Time) tea.Msg { return search() })
+}
+
+// stopAI 停止自动游玩并丢弃还没算完的结果
+func (m *Game2048Model) stopAI() {
+	m.autoplay = false
+	m.aiID++
+}
+
+// updateAI 显示提示，或者在自动游玩时走一步并安排下一步
+func (m *Game2048Model) updateAI(msg game2048AIMsg) tea.Cmd {
+	if msg.id != m.aiID || m.game.GameOver {
+		return nil
+	}
+	if !msg.ok {
+		m.autoplay = false
+		return nil
+	}
+
+	m.hints++
+	if !m.autoplay {
+		m.hint = msg.dir
+		return nil
+	}
+
+	cmd := m.move(msg.dir)
+	// 自动游玩拼出目标后总是继续
+	m.game.Continue()
+	m.finish()
+	if m.game.GameOver {
+		m.autoplay = false
+		return cmd
+	}
+	return tea.Batch(cmd, m.think(autoplaySpeeds[m.autoSpeed]))
+}
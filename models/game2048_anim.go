@@ -0,0 +1,118 @@
+package models
+
+import (
+	"time"
+
+	"termiplay/go-backend/game"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// 动画的帧：先用 slideFrames 帧把方块滑到终点，然后一帧突出合并的方块，
+// 最后一帧让新方块淡入
+const (
+	slideFrames    = 3
+	mergeFrame     = slideFrames
+	spawnFrame     = mergeFrame + 1
+	game2048Frames = spawnFrame + 1
+
+	frameInterval = 35 * time.Millisecond
+)
+
+var (
+	mergePopStyle = lipgloss.NewStyle().
+			Background(lipgloss.Color("231")).
+			Foreground(lipgloss.Color("0"))
+
+	spawnFadeStyle = lipgloss.NewStyle().
+			Background(lipgloss.Color("236")).
+			Foreground(lipgloss.Color("243"))
+)
+
+// game2048FrameMsg 推进动画，id 用来丢弃已经被新的按键打断的动画
+type game2048FrameMsg struct{ id int }
+
+// game2048Anim 根据一次移动的结果画出中间帧
+type game2048Anim struct {
+	result game.MoveResult
+	frame  int
+}
+
+// startAnimation 开始播放移动 r 的动画
+func (m *Game2048Model) startAnimation(r game.MoveResult) tea.Cmd {
+	m.anim = &game2048Anim{result: r}
+	return m.scheduleFrame()
+}
+
+// stopAnimation 结束动画，直接显示最终的棋盘
+func (m *Game2048Model) stopAnimation() {
+	m.anim = nil
+	m.tickID++
+}
+
+func (m *Game2048Model) scheduleFrame() tea.Cmd {
+	m.tickID++
+	id := m.tickID
+	return tea.Tick(frameInterval, func(time.Time) tea.Msg { return game2048FrameMsg{id: id} })
+}
+
+// nextFrame 显示下一帧，最后一帧之后结束动画
+func (m *Game2048Model) nextFrame(msg game2048FrameMsg) tea.Cmd {
+	if m.anim == nil || msg.id != m.tickID {
+		return nil
+	}
+	m.anim.frame++
+	if m.anim.frame >= game2048Frames {
+		m.anim = nil
+		return nil
+	}
+	return m.scheduleFrame()
+}
+
+// grid 返回当前帧中每个格子显示的数值
+func (a *game2048Anim) grid(g *game.Game2048) [][]int {
+	if a.frame >= mergeFrame {
+		grid := make([][]int, g.Size)
+		for y := range grid {
+			grid[y] = append([]int(nil), g.Grid[y]...)
+		}
+		// 新方块在淡入之前不显示
+		if s := a.result.Spawn; s != nil && a.frame < spawnFrame {
+			grid[s.At.Y][s.At.X] = 0
+		}
+		return grid
+	}
+
+	// 滑动中的方块按进度放在起点和终点之间，合并的两个方块显示原来的数值
+	grid := make([][]int, g.Size)
+	for y := range grid {
+		grid[y] = make([]int, g.Size)
+	}
+	for _, s := range a.result.Slides {
+		x := s.From.X + (s.To.X-s.From.X)*a.frame/slideFrames
+		y := s.From.Y + (s.To.Y-s.From.Y)*a.frame/slideFrames
+		grid[y][x] = max(grid[y][x], s.Value)
+	}
+	return grid
+}
+
+// style 突出合并出的方块和正在淡入的新方块
+func (a *game2048Anim) style(p game.Point, s lipgloss.Style) lipgloss.Style {
+	switch a.frame {
+	case mergeFrame:
+		for _, mg := range a.result.Merges {
+			if mg.At == p {
+				return s.Background(mergePopStyle.GetBackground()).
+					Foreground(mergePopStyle.GetForeground()).
+					Bold(true)
+			}
+		}
+	case spawnFrame:
+		if sp := a.result.Spawn; sp != nil && sp.At == p {
+			return s.Background(spawnFadeStyle.GetBackground()).
+				Foreground(spawnFadeStyle.GetForeground())
+		}
+	}
+	return s
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"termiplay/go-backend/game"
+	"termiplay/go-backend/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// game2048Modes 是设置界面中可选的模式和说明
+var game2048Modes = []struct {
+	mode        game2048Mode
+	description string
+}{
+	{rankedMode, "不能撤销，成绩计入排行榜"},
+	{practiceMode, "可以无限撤销，成绩会标记撤销次数"},
+}
+
+// 设置界面中的各行
+const (
+	setupMode = iota
+	setupSize
+	setupTarget
+	setupRows
+)
+
+// Game2048SetupModel 让玩家在开始 2048 之前选择模式、棋盘大小和目标
+type Game2048SetupModel struct {
+	session *registry.Session
+	row     int
+	mode    int // game2048Modes 中的下标
+	size    int
+	target  int // game.Game2048Targets 中的下标
+	err     string
+}
+
+func NewGame2048SetupModel(s *registry.Session, cfg game.Game2048Config) *Game2048SetupModel {
+	m := &Game2048SetupModel{
+		session: s,
+		size:    cfg.Size,
+	}
+	for i, t := range game.Game2048Targets {
+		if t == cfg.Target {
+			m.target = i
+		}
+	}
+	return m
+}
+
+func (m *Game2048SetupModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *Game2048SetupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		m.err = ""
+		switch msg.String() {
+		case "up", "k":
+			if m.row > 0 {
+				m.row--
+			}
+		case "down", "j", "tab":
+			if m.row < setupRows-1 {
+				m.row++
+			}
+		case "left", "h":
+			m.change(-1)
+		case "right", "l":
+			m.change(1)
+		case "enter", " ":
+			cfg := m.config()
+			if err := cfg.Validate(); err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
+			return NewGame2048Model(m.session, game2048Modes[m.mode].mode, cfg), nil
+		case "q", "esc":
+			return m, registry.Exit
+		}
+	}
+	return m, nil
+}
+
+// change 把当前行的值向前或向后调整一档，到头后停住
+func (m *Game2048SetupModel) change(delta int) {
+	switch m.row {
+	case setupMode:
+		m.mode = clamp(m.mode+delta, 0, len(game2048Modes)-1)
+	case setupSize:
+		m.size = clamp(m.size+delta, game.Min2048Size, game.Max2048Size)
+	case setupTarget:
+		m.target = clamp(m.target+delta, 0, len(game.Game2048Targets)-1)
+	}
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
+
+func (m *Game2048SetupModel) config() game.Game2048Config {
+	return game.Game2048Config{Size: m.size, Target: game.Game2048Targets[m.target]}
+}
+
+func (m *Game2048SetupModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("🔢 2048"))
+	b.WriteString("\n\n")
+
+	rows := []struct{ name, value, description string }{
+		{"模式", game2048Modes[m.mode].mode.String(), game2048Modes[m.mode].description},
+		{"边长", fmt.Sprintf("%d×%d", m.size, m.size), ""},
+		{"目标", fmt.Sprintf("%d", game.Game2048Targets[m.target]), ""},
+	}
+	for i, r := range rows {
+		cursor := " "
+		style := menuItemStyle
+		if m.row == i {
+			cursor = ">"
+			style = selectedStyle
+		}
+		b.WriteString(fmt.Sprintf("%s %s ◀ %s ▶ %s\n", cursor, style.Render(r.name),
+			inputStyle.Render(r.value), descriptionStyle.Render(r.description)))
+	}
+
+	if m.err != "" {
+		b.WriteString("\n" + errorStyle.Render(m.err) + "\n")
+	}
+
+	b.WriteString(lobbyHelpStyle.Render("↑/↓ 选择 | ←/→ 调整 | Enter 开始 | Q 返回大厅"))
+	return b.String()
+}
@@ -0,0 +1,212 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"termiplay/go-backend/game"
+	"termiplay/go-backend/registry"
+	"termiplay/go-backend/store"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// leaderboardSize 是每个榜单显示的名次数
+const leaderboardSize = 10
+
+var (
+	tabStyle = lipgloss.NewStyle().
+			Padding(0, 1).
+			Foreground(lipgloss.Color("240"))
+
+	activeTabStyle = lipgloss.NewStyle().
+			Padding(0, 1).
+			Foreground(lipgloss.Color("255")).
+			Background(lipgloss.Color("205")).
+			Bold(true)
+
+	rankStyle = lipgloss.NewStyle().
+			Width(4).
+			Foreground(lipgloss.Color("220"))
+
+	nameColumnStyle = lipgloss.NewStyle().
+			Width(18)
+
+	valueColumnStyle = lipgloss.NewStyle().
+				Width(10).
+				Align(lipgloss.Right).
+				MarginRight(3)
+
+	ownRowStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("39")).
+			Bold(true)
+
+	leaderboardHelpStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("241")).
+				MarginTop(1)
+)
+
+// leaderboardTab 是排行榜中的一个榜单
+type leaderboardTab struct {
+	title string
+	board store.Board
+}
+
+type LeaderboardModel struct {
+	session *registry.Session
+	title   string
+	tabs    []leaderboardTab
+	tab     int
+	entries []store.Result
+	err     error
+}
+
+func NewLeaderboardModel(s *registry.Session) *LeaderboardModel {
+	return newLeaderboardModel(s, "🏆 排行榜", leaderboardTabs(s))
+}
+
+// leaderboardTabs 返回总排行榜中的所有榜单
+func leaderboardTabs(s *registry.Session) []leaderboardTab {
+	tabs := []leaderboardTab{
+		{title: "2048 最高分", board: store.Board{Game: Game2048Game{}.ID()}},
+	}
+	tabs = append(tabs, game2048VariantTabs(s)...)
+	for _, d := range []game.Difficulty{game.Easy, game.Medium, game.Hard} {
+		tabs = append(tabs, leaderboardTab{
+			title: "扫雷 " + difficultyNames[d],
+			board: store.Board{Game: MinesweeperGame{}.ID(), Variant: d.String(), Fastest: true},
+		})
+	}
+	return tabs
+}
+
+// game2048VariantTabs 为玩家玩过的每种 2048 棋盘设置生成一个榜单
+func game2048VariantTabs(s *registry.Session) []leaderboardTab {
+	results, err := s.Store.Results(func(r store.Result) bool {
+		return r.Game == Game2048Game{}.ID() && r.Variant != "" && r.Daily == ""
+	})
+	if err != nil {
+		return nil
+	}
+
+	var variants []string
+	for _, r := range results {
+		if !slices.Contains(variants, r.Variant) {
+			variants = append(variants, r.Variant)
+		}
+	}
+	slices.Sort(variants)
+
+	tabs := make([]leaderboardTab, len(variants))
+	for i, v := range variants {
+		tabs[i] = leaderboardTab{
+			title: "2048 " + v,
+			board: store.Board{Game: Game2048Game{}.ID(), Variant: v},
+		}
+	}
+	return tabs
+}
+
+// NewDailyLeaderboardModel 显示每日挑战 day 的排行榜
+func NewDailyLeaderboardModel(s *registry.Session, day string) *LeaderboardModel {
+	tabs := []leaderboardTab{
+		{title: "2048", board: store.Board{Game: Game2048Game{}.ID(), Daily: day}},
+		{title: "扫雷", board: store.Board{
+			Game:    MinesweeperGame{}.ID(),
+			Variant: dailyDifficulty.String(),
+			Daily:   day,
+			Fastest: true,
+		}},
+	}
+	return newLeaderboardModel(s, "🏆 每日挑战排行榜 "+day, tabs)
+}
+
+func newLeaderboardModel(s *registry.Session, title string, tabs []leaderboardTab) *LeaderboardModel {
+	m := &LeaderboardModel{
+		session: s,
+		title:   title,
+		tabs:    tabs,
+	}
+	m.load()
+	return m
+}
+
+func (m *LeaderboardModel) load() {
+	m.entries, m.err = m.session.Store.Leaderboard(m.tabs[m.tab].board, leaderboardSize)
+}
+
+func (m *LeaderboardModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *LeaderboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "left", "h", "a", "shift+tab":
+			m.tab = (m.tab + len(m.tabs) - 1) % len(m.tabs)
+			m.load()
+		case "right", "l", "d", "tab":
+			m.tab = (m.tab + 1) % len(m.tabs)
+			m.load()
+		case "q", "esc":
+			return m, registry.Exit
+		}
+	}
+	return m, nil
+}
+
+func (m *LeaderboardModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(m.title))
+	b.WriteString("\n\n")
+
+	tabs := make([]string, len(m.tabs))
+	for i, t := range m.tabs {
+		if i == m.tab {
+			tabs[i] = activeTabStyle.Render(t.title)
+		} else {
+			tabs[i] = tabStyle.Render(t.title)
+		}
+	}
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, tabs...))
+	b.WriteString("\n\n")
+
+	switch {
+	case m.err != nil:
+		b.WriteString(errorStyle.Render("读取排行榜失败: " + m.err.Error()))
+		b.WriteString("\n")
+	case len(m.entries) == 0:
+		b.WriteString(descriptionStyle.Render("还没有记录，快去创造第一个吧！"))
+		b.WriteString("\n")
+	default:
+		for i, r := range m.entries {
+			line := nameColumnStyle.Render(r.Nickname) +
+				valueColumnStyle.Render(m.formatEntry(r)) +
+				r.FinishedAt.Format("2006-01-02")
+			if r.PlayerID == m.session.Player.ID {
+				line = ownRowStyle.Render(line)
+			}
+			// 拼出目标后继续玩的成绩
+			if r.Continued {
+				line += descriptionStyle.Render(" ➜ 继续挑战")
+			}
+			b.WriteString(rankStyle.Render(fmt.Sprintf("%d.", i+1)) + line + "\n")
+		}
+	}
+
+	help := "←/→ 切换榜单 | Q 返回大厅"
+	b.WriteString(leaderboardHelpStyle.Render(help))
+
+	return b.String()
+}
+
+func (m *LeaderboardModel) formatEntry(r store.Result) string {
+	if m.tabs[m.tab].board.Fastest {
+		return fmt.Sprintf("%.1f秒", r.Duration.Seconds())
+	}
+	return fmt.Sprintf("%d", r.Score)
+}
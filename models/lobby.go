@@ -3,14 +3,16 @@ package models
 import (
 	"fmt"
 	"strings"
+	"time"
+	"unicode"
+
+	"termiplay/go-backend/game"
+	"termiplay/go-backend/player"
+	"termiplay/go-backend/registry"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-)
-
-const (
-	Minesweeper = iota
-	Game2048
+	"github.com/charmbracelet/log"
 )
 
 var (
@@ -29,32 +31,170 @@ var (
 			Foreground(lipgloss.Color("205")).
 			Bold(true)
 
+	descriptionStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("238"))
+
+	playerStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("39"))
+
+	inputStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("255")).
+			Bold(true)
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196"))
+
 	lobbyHelpStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241")).
 			MarginTop(2)
 )
 
+const lobbyTitle = "🎮 欢迎来到 TermiPlay 游戏大厅 🎮"
+
+// lobbyItem 是大厅菜单中的一项
+type lobbyItem struct {
+	id          string // 对应的游戏 ID，其他入口为空
+	name        string
+	description string
+	cmd         tea.Cmd
+}
+
 type LobbyModel struct {
-	choices    []string
-	cursor     int
-	selected   int
-	gameChosen bool
+	session *registry.Session
+	choices []lobbyItem
+	cursor  int
+
+	err string
+
+	// 昵称输入状态，第一次登录的玩家必须先起一个昵称
+	naming   bool
+	input    []rune
+	inputErr string
 }
 
-func NewLobbyModel() *LobbyModel {
+func NewLobbyModel(s *registry.Session) *LobbyModel {
 	return &LobbyModel{
-		choices: []string{"扫雷 (Minesweeper)", "2048"},
+		session: s,
+		choices: lobbyItems(s),
 		cursor:  0,
+		naming:  s.Player.NeedsNickname(),
 	}
 }
 
+// lobbyErrorMsg 报告无法打开选中的入口，例如存档已损坏
+type lobbyErrorMsg struct{ err error }
+
+// lobbyItems 列出玩家的存档、注册表中的游戏以及其他界面的入口
+func lobbyItems(s *registry.Session) []lobbyItem {
+	items := resumeItems(s)
+	for _, g := range registry.All() {
+		items = append(items, lobbyItem{
+			id:          g.ID(),
+			name:        g.Name(),
+			description: g.Description(),
+			cmd:         registry.Launch(g),
+		})
+	}
+	items = append(items, lobbyItem{
+		name:        "📅 每日挑战",
+		description: "和所有人挑战同一局",
+		cmd: func() tea.Msg {
+			return registry.OpenMsg{Model: NewDailyModel(s, game.DailyKey(time.Now()))}
+		},
+	})
+	items = append(items, lobbyItem{
+		name:        "🏆 排行榜",
+		description: "查看最高分和最快通关",
+		cmd: func() tea.Msg {
+			return registry.OpenMsg{Model: NewLeaderboardModel(s)}
+		},
+	})
+	items = append(items, lobbyItem{
+		name:        "🎬 录像",
+		description: "回放精彩对局",
+		cmd: func() tea.Msg {
+			return registry.OpenMsg{Model: NewReplaysModel(s)}
+		},
+	})
+	items = append(items, lobbyItem{
+		name:        "⚙️ 设置",
+		description: "调整个人偏好",
+		cmd: func() tea.Msg {
+			return registry.OpenMsg{Model: NewSettingsModel(s)}
+		},
+	})
+	return items
+}
+
+// resumeItems 为玩家的每个存档生成一个“继续”入口
+func resumeItems(s *registry.Session) []lobbyItem {
+	if s.Player.IsGuest() {
+		return nil
+	}
+	saves, err := s.Store.Saves(s.Player.ID)
+	if err != nil {
+		log.Error("Could not load saves", "player", s.Player.ID, "error", err)
+		return nil
+	}
+
+	var items []lobbyItem
+	for _, sv := range saves {
+		g, ok := registry.Lookup(sv.Game)
+		if !ok {
+			continue
+		}
+		rg, ok := g.(registry.ResumableGame)
+		if !ok {
+			continue
+		}
+
+		name := "▶ 继续 " + g.Name()
+		if sv.Daily != "" {
+			name += " (每日挑战 " + sv.Daily + ")"
+		}
+		items = append(items, lobbyItem{
+			name:        name,
+			description: "保存于 " + sv.SavedAt.Format("01-02 15:04"),
+			cmd: func() tea.Msg {
+				m, err := rg.Resume(s, sv.Data)
+				if err != nil {
+					return lobbyErrorMsg{err}
+				}
+				return registry.OpenMsg{Model: m}
+			},
+		})
+	}
+	return items
+}
+
 func (m *LobbyModel) Init() tea.Cmd {
 	return nil
 }
 
 func (m *LobbyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case lobbyErrorMsg:
+		m.err = "无法打开: " + msg.err.Error()
+	case tea.MouseMsg:
+		// 单击选中菜单项，再次单击已选中的项打开它
+		if m.naming || msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+			return m, nil
+		}
+		i, ok := m.itemAt(msg.Y)
+		if !ok {
+			return m, nil
+		}
+		m.err = ""
+		if i == m.cursor {
+			return m, m.choices[i].cmd
+		}
+		m.cursor = i
 	case tea.KeyMsg:
+		if m.naming {
+			return m.updateNickname(msg)
+		}
+		m.err = ""
+
 		switch msg.String() {
 		case "up", "k":
 			if m.cursor > 0 {
@@ -65,11 +205,63 @@ func (m *LobbyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor++
 			}
 		case "enter", " ":
-			m.selected = m.cursor
-			m.gameChosen = true
+			if len(m.choices) > 0 {
+				return m, m.choices[m.cursor].cmd
+			}
+		case "n":
+			if !m.session.Player.IsGuest() {
+				m.naming = true
+				m.input = []rune(m.session.Player.Nickname)
+			}
 		case "q", "ctrl+c":
-			m.selected = -1
-			m.gameChosen = true
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// updateNickname 处理昵称输入框中的按键
+func (m *LobbyModel) updateNickname(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		name := strings.TrimSpace(string(m.input))
+		if err := player.ValidateNickname(name); err != nil {
+			m.inputErr = err.Error()
+			return m, nil
+		}
+
+		p := m.session.Player
+		p.Nickname = name
+		if p.CreatedAt.IsZero() {
+			p.CreatedAt = time.Now()
+		}
+		if err := m.session.Store.SavePlayer(p); err != nil {
+			m.inputErr = "保存失败: " + err.Error()
+			return m, nil
+		}
+		m.session.Player = p
+		m.naming = false
+		m.inputErr = ""
+	case tea.KeyEsc:
+		// 第一次登录时不能跳过
+		if !m.session.Player.NeedsNickname() {
+			m.naming = false
+			m.inputErr = ""
+		}
+	case tea.KeyBackspace:
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case tea.KeySpace:
+		if len(m.input) < player.MaxNicknameLength {
+			m.input = append(m.input, ' ')
+		}
+	case tea.KeyRunes:
+		// 粘贴的内容也会作为字符送来，其中可能有控制字符
+		for _, r := range msg.Runes {
+			if unicode.IsPrint(r) && len(m.input) < player.MaxNicknameLength {
+				m.input = append(m.input, r)
+			}
 		}
 	}
 	return m, nil
@@ -78,9 +270,14 @@ func (m *LobbyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m *LobbyModel) View() string {
 	var b strings.Builder
 
-	b.WriteString(titleStyle.Render("🎮 欢迎来到 TermiPlay 游戏大厅 🎮"))
+	b.WriteString(titleStyle.Render(lobbyTitle))
 	b.WriteString("\n\n")
-	b.WriteString("请选择游戏：\n\n")
+
+	if m.naming {
+		return m.viewNickname(&b)
+	}
+
+	b.WriteString(m.viewMenuHeader())
 
 	for i, choice := range m.choices {
 		cursor := " "
@@ -95,19 +292,54 @@ func (m *LobbyModel) View() string {
 			style = menuItemStyle
 		}
 
-		b.WriteString(fmt.Sprintf("%s %s\n", cursor, style.Render(choice)))
+		b.WriteString(fmt.Sprintf("%s %s %s\n", cursor, style.Render(choice.name),
+			descriptionStyle.Render(choice.description)))
+	}
+
+	if m.err != "" {
+		b.WriteString("\n" + errorStyle.Render(m.err) + "\n")
 	}
 
 	b.WriteString("\n")
-	b.WriteString(lobbyHelpStyle.Render("↑/↓ 选择 | Enter 确认 | q 退出"))
+	help := "↑/↓ 选择 | Enter 确认 | q 退出"
+	if !m.session.Player.IsGuest() {
+		help = "↑/↓ 选择 | Enter 确认 | n 修改昵称 | q 退出"
+	}
+	b.WriteString(lobbyHelpStyle.Render(help))
 
 	return b.String()
 }
 
-func (m *LobbyModel) GetSelected() int {
-	return m.selected
+// viewMenuHeader 渲染菜单上方的玩家信息和提示
+func (m *LobbyModel) viewMenuHeader() string {
+	return playerStyle.Render("玩家: "+m.session.Player.DisplayName()) + "\n\n" +
+		"请选择游戏：\n\n"
+}
+
+// itemAt 返回终端第 row 行对应的菜单项，菜单项从标题和菜单头下方开始，每项占一行
+func (m *LobbyModel) itemAt(row int) (int, bool) {
+	top := strings.Count(titleStyle.Render(lobbyTitle)+"\n\n"+m.viewMenuHeader(), "\n")
+	i := row - top
+	return i, i >= 0 && i < len(m.choices)
 }
 
-func (m *LobbyModel) IsGameChosen() bool {
-	return m.gameChosen
+func (m *LobbyModel) viewNickname(b *strings.Builder) string {
+	if m.session.Player.NeedsNickname() {
+		b.WriteString("第一次来？请给自己起个昵称：\n\n")
+	} else {
+		b.WriteString("修改昵称：\n\n")
+	}
+	b.WriteString("> " + inputStyle.Render(string(m.input)+"█"))
+	b.WriteString("\n")
+	if m.inputErr != "" {
+		b.WriteString("\n" + errorStyle.Render(m.inputErr) + "\n")
+	}
+
+	help := "Enter 确认"
+	if !m.session.Player.NeedsNickname() {
+		help += " | Esc 取消"
+	}
+	b.WriteString(lobbyHelpStyle.Render(help))
+
+	return b.String()
 }
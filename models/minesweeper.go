@@ -1,13 +1,21 @@
 package models
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
+	"termiplay/go-backend/config"
 	"termiplay/go-backend/game"
+	"termiplay/go-backend/registry"
+	"termiplay/go-backend/store"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
 )
 
 var (
@@ -43,11 +51,35 @@ var (
 				Foreground(lipgloss.Color("255")).
 				Bold(true)
 
+	questionStyle = cellStyle.Copy().
+			Background(lipgloss.Color("240")).
+			Foreground(lipgloss.Color("226")).
+			Bold(true)
+
 	mineStyle = cellStyle.Copy().
 			Background(lipgloss.Color("196")).
 			Foreground(lipgloss.Color("255")).
 			Bold(true)
 
+	explodedStyle = cellStyle.Copy().
+			Background(lipgloss.Color("226")).
+			Foreground(lipgloss.Color("255")).
+			Bold(true)
+
+	wrongFlagStyle = cellStyle.Copy().
+			Background(lipgloss.Color("240")).
+			Foreground(lipgloss.Color("196")).
+			Bold(true)
+
+	hintCellStyle = cellStyle.Copy().
+			Background(lipgloss.Color("34")).
+			Foreground(lipgloss.Color("255")).
+			Bold(true)
+
+	pausedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("205")).
+			Bold(true)
+
 	minesweeperInfoStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("240")).
 				MarginTop(1)
@@ -57,26 +89,224 @@ var (
 				MarginTop(1)
 )
 
+// MinesweeperGame 是扫雷在注册表中的描述。
+type MinesweeperGame struct {
+	// FirstClick 决定第一次翻开时哪些格子保证没有雷
+	FirstClick game.FirstClickSafety
+	// NoGuess 为 true 时只生成不需要猜测就能解开的雷区
+	NoGuess bool
+	// Difficulty 是难度选择界面中默认选中的难度
+	Difficulty game.Difficulty
+}
+
+func init() {
+	registry.Register(MinesweeperGame{}, 10)
+}
+
+func (MinesweeperGame) ID() string          { return "minesweeper" }
+func (MinesweeperGame) Name() string        { return "扫雷 (Minesweeper)" }
+func (MinesweeperGame) Description() string { return "翻开所有没有雷的格子" }
+
+// Configure 使用配置中默认选中的难度
+func (g MinesweeperGame) Configure(c config.Config) registry.Game {
+	g.Difficulty = c.Difficulty()
+	return g
+}
+
+// New 先让玩家选择难度
+func (g MinesweeperGame) New(s *registry.Session) tea.Model {
+	return NewDifficultyModel(s, g)
+}
+
+func (MinesweeperGame) Usage() string {
+	return "[--easy | --medium | --hard] [--no-guess]"
+}
+
+// NewWithArgs 跳过难度选择，直接按参数开始一局。雷区放不进终端时
+// 留在难度选择界面并说明原因
+func (g MinesweeperGame) NewWithArgs(s *registry.Session, args []string) (tea.Model, error) {
+	for _, a := range args {
+		switch a {
+		case "--easy", "--medium", "--hard":
+			g.Difficulty, _ = game.ParseDifficulty(strings.TrimPrefix(a, "--"))
+		case "--no-guess":
+			g.NoGuess = true
+		default:
+			return nil, fmt.Errorf("未知的参数 %q", a)
+		}
+	}
+	m := NewDifficultyModel(s, g)
+	m.pending = true
+	return m, nil
+}
+
+func (g MinesweeperGame) NewDaily(s *registry.Session, day string) tea.Model {
+	return g.newModel(s, dailyDifficulty, dailyDifficulty.Config(), day)
+}
+
+// newModel 创建一局新游戏，daily 为每日挑战的日期
+func (g MinesweeperGame) newModel(s *registry.Session, difficulty game.Difficulty, cfg game.MinesweeperConfig, daily string) *MinesweeperModel {
+	m := &MinesweeperModel{
+		session:    s,
+		difficulty: difficulty,
+		config:     cfg,
+		firstClick: g.FirstClick,
+		noGuess:    g.NoGuess,
+		questions:  s.Player.Settings.QuestionMarks,
+		daily:      daily,
+	}
+	m.game = m.newGame()
+	m.resetCursor()
+	return m
+}
+
+func (MinesweeperGame) Resume(s *registry.Session, data []byte) (tea.Model, error) {
+	var sv minesweeperSave
+	if err := json.Unmarshal(data, &sv); err != nil {
+		return nil, err
+	}
+	if sv.Game == nil {
+		return nil, errors.New("存档中没有对局")
+	}
+	// 离线的时间不计入用时
+	sv.Game.SetElapsed(sv.Elapsed)
+	return &MinesweeperModel{
+		session:    s,
+		game:       sv.Game,
+		cursorX:    sv.CursorX,
+		cursorY:    sv.CursorY,
+		difficulty: sv.Difficulty,
+		config: game.MinesweeperConfig{
+			Width:  sv.Game.Width,
+			Height: sv.Game.Height,
+			Mines:  sv.Game.MineCount,
+		},
+		firstClick: sv.Game.FirstClick,
+		noGuess:    sv.Game.NoGuess,
+		questions:  sv.Game.Questions,
+		daily:      sv.Daily,
+		hints:      sv.Hints,
+	}, nil
+}
+
+// minesweeperSave 是扫雷存档的内容
+type minesweeperSave struct {
+	Game       *game.Minesweeper
+	Difficulty game.Difficulty
+	Daily      string
+	Elapsed    time.Duration
+	CursorX    int
+	CursorY    int
+	Hints      int
+}
+
+func (MinesweeperGame) NewPlayback(data []byte) (registry.Playback, error) {
+	var r minesweeperReplay
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+
+	var m *MinesweeperModel
+	p := &actionPlayback{
+		actions: r.Actions,
+		reset: func() {
+			m = &MinesweeperModel{
+				game:       game.NewCustomMinesweeper(r.Config, r.Seed),
+				difficulty: r.Difficulty,
+				config:     r.Config,
+				hints:      r.Hints,
+				replaying:  true,
+			}
+			m.game.FirstClick = r.FirstClick
+			m.game.NoGuess = r.NoGuess
+			m.game.Questions = r.Questions
+		},
+		apply: func(a game.Action) {
+			m.game.Apply(a)
+			m.cursorX, m.cursorY = a.X, a.Y
+			m.replayTime = a.At
+			m.showWin = m.game.GameOver
+		},
+		view: func() string { return m.render() },
+	}
+	p.Rewind()
+	return p, nil
+}
+
+// minesweeperReplay 是扫雷录像的内容
+type minesweeperReplay struct {
+	Difficulty game.Difficulty
+	Config     game.MinesweeperConfig
+	FirstClick game.FirstClickSafety
+	NoGuess    bool
+	Questions  bool
+	Seed       int64
+	Hints      int
+	Actions    []game.Action
+}
+
+// dailyDifficulty 是每日挑战使用的难度
+const dailyDifficulty = game.Medium
+
+// customVariantPrefix 是自定义难度成绩的变体前缀
+const customVariantPrefix = "custom-"
+
+var difficultyNames = map[game.Difficulty]string{
+	game.Easy:   "简单",
+	game.Medium: "中等",
+	game.Hard:   "困难",
+	game.Custom: "自定义",
+}
+
 type MinesweeperModel struct {
+	session    *registry.Session
 	game       *game.Minesweeper
 	cursorX    int
 	cursorY    int
 	difficulty game.Difficulty
+	config     game.MinesweeperConfig
+	firstClick game.FirstClickSafety
+	noGuess    bool
+	questions  bool // 标记时是否经过问号
 	showWin    bool
+	recorded   bool
+	daily      string // 每日挑战的日期，普通对局为空
+	status     string // 显示在信息栏中的提示，例如保存结果
+	replayID   string // 本局录像的编号
+	tickID     int    // 用来丢弃过期的计时消息
+	width      int    // 终端的宽度，信息栏按它折行
+
+	// 左键和右键是否按着，两个键同时按下时连开
+	leftDown  bool
+	rightDown bool
+
+	// 提示的格子只在雷区没有变化时显示，hintAt 是给出提示时的操作数
+	hint     *game.Point
+	hintProb float64
+	hintAt   int
+	hints    int // 本局使用提示的次数
+
+	// 回放录像时使用录像中的时间，而不是真实时间
+	replaying  bool
+	replayTime time.Duration
 }
 
-func NewMinesweeperModel(difficulty game.Difficulty) *MinesweeperModel {
-	return &MinesweeperModel{
-		game:       game.NewMinesweeper(difficulty),
-		cursorX:    0,
-		cursorY:    0,
-		difficulty: difficulty,
-		showWin:    false,
-	}
-}
+// minesweeperTickMsg 每秒刷新一次计时，id 用来丢弃暂停或重新开始之前安排的消息
+type minesweeperTickMsg struct{ id int }
 
 func (m *MinesweeperModel) Init() tea.Cmd {
-	return nil
+	return m.schedule()
+}
+
+// schedule 在计时进行中时安排下一次刷新，对齐到整秒
+func (m *MinesweeperModel) schedule() tea.Cmd {
+	m.tickID++
+	if m.replaying || !m.game.Started() || m.game.GameOver || m.game.Paused() {
+		return nil
+	}
+	id := m.tickID
+	d := time.Second - m.game.GetElapsedTime()%time.Second
+	return tea.Tick(d, func(time.Time) tea.Msg { return minesweeperTickMsg{id: id} })
 }
 
 func (m *MinesweeperModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -86,8 +316,24 @@ func (m *MinesweeperModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	switch msg := msg.(type) {
+	case minesweeperTickMsg:
+		if msg.id != m.tickID {
+			return m, nil
+		}
+		return m, m.schedule()
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		return m, nil
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
 	case tea.KeyMsg:
-		switch msg.String() {
+		m.status = ""
+		key := msg.String()
+		// 暂停时隐藏了雷区，只能继续、保存或退出
+		if m.game.Paused() && key != "p" && key != "ctrl+s" && key != "q" {
+			return m, nil
+		}
+		switch key {
 		case "up", "k", "w":
 			if m.cursorY > 0 {
 				m.cursorY--
@@ -106,42 +352,298 @@ func (m *MinesweeperModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case " ", "enter":
 			if !m.game.GameOver {
-				m.game.Reveal(m.cursorX, m.cursorY)
+				m.reveal()
+			}
+		case "c":
+			if !m.game.GameOver {
+				m.chord()
 			}
 		case "f":
 			if !m.game.GameOver {
 				m.game.ToggleFlag(m.cursorX, m.cursorY)
 			}
+		case "t":
+			if !m.game.GameOver {
+				m.showHint()
+			}
 		case "r":
 			if m.game.GameOver {
-				m.game = game.NewMinesweeper(m.difficulty)
-				m.cursorX = 0
-				m.cursorY = 0
+				m.game = m.newGame()
+				m.resetCursor()
 				m.showWin = false
+				m.recorded = false
+				m.replayID = ""
+				m.hint = nil
+				m.hints = 0
+			}
+		case "p":
+			if m.game.Paused() {
+				m.game.Resume()
+			} else {
+				m.game.Pause()
+			}
+		case "ctrl+s":
+			if !m.game.GameOver {
+				m.status = saveStatus(m.save())
 			}
-		case "q", "ctrl+c":
-			return m, tea.Quit
+		case "q":
+			m.tickID++
+			return m, m.leave()
 		}
+		return m, m.schedule()
 	}
 	return m, nil
 }
 
+// newGame 按当前设置开始新的一局，每日挑战总是使用当天的种子
+func (m *MinesweeperModel) newGame() *game.Minesweeper {
+	seed := game.NewSeed()
+	if m.daily != "" {
+		seed = game.DailySeed(m.daily)
+	}
+	g := game.NewCustomMinesweeper(m.config, seed)
+	g.FirstClick = m.firstClick
+	g.NoGuess = m.noGuess
+	g.Questions = m.questions
+	// 雷区和第一次翻开的位置有关，每日挑战替所有玩家从同一个格子开局
+	if m.daily != "" {
+		g.Open()
+	}
+	return g
+}
+
+// resetCursor 把光标放回左上角，每日挑战放在开局的格子上
+func (m *MinesweeperModel) resetCursor() {
+	m.cursorX, m.cursorY = 0, 0
+	if m.daily != "" {
+		p := m.game.Opening()
+		m.cursorX, m.cursorY = p.X, p.Y
+	}
+}
+
+// Save 保存进行中的对局，还没有翻开任何格子时不保存
+func (m *MinesweeperModel) Save() error {
+	if m.game.GameOver || m.game.Revealed == 0 {
+		return nil
+	}
+	return m.save()
+}
+
+// leave 返回大厅。每日挑战离开时总是保存，再次进入时从存档继续，
+// 不能凭着已经看过的雷区重新开始
+func (m *MinesweeperModel) leave() tea.Cmd {
+	if m.daily != "" && !m.session.Player.IsGuest() {
+		if err := m.Save(); err != nil {
+			log.Error("Could not save daily game", "player", m.session.Player.ID, "error", err)
+		}
+	}
+	return registry.Exit
+}
+
+func (m *MinesweeperModel) save() error {
+	return saveGame(m.session, MinesweeperGame{}.ID(), m.daily, minesweeperSave{
+		Game:       m.game,
+		Difficulty: m.difficulty,
+		Daily:      m.daily,
+		Elapsed:    m.game.GetElapsedTime(),
+		CursorX:    m.cursorX,
+		CursorY:    m.cursorY,
+		Hints:      m.hints,
+	})
+}
+
+// finish 在游戏刚结束时保存结果并删除存档
+func (m *MinesweeperModel) finish() {
+	if !m.game.GameOver || m.recorded {
+		return
+	}
+	m.recorded = true
+	deleteSave(m.session, MinesweeperGame{}.ID(), m.daily)
+	m.replayID = recordResult(m.session, store.Result{
+		Game:     MinesweeperGame{}.ID(),
+		Variant:  m.variant(),
+		Duration: m.game.GetElapsedTime(),
+		Won:      m.game.Won,
+		Seed:     m.game.Seed,
+		Daily:    m.daily,
+		Hints:    m.hints,
+	}, minesweeperReplay{
+		Difficulty: m.difficulty,
+		Config:     m.config,
+		FirstClick: m.firstClick,
+		NoGuess:    m.noGuess,
+		Questions:  m.questions,
+		Seed:       m.game.Seed,
+		Hints:      m.hints,
+		Actions:    m.game.Actions,
+	})
+}
+
+// variant 返回记录成绩时使用的变体，自定义难度带上雷区设置
+func (m *MinesweeperModel) variant() string {
+	if m.difficulty == game.Custom {
+		return customVariantPrefix + m.config.String()
+	}
+	return m.difficulty.String()
+}
+
 func (m *MinesweeperModel) View() string {
+	if m.game.GameOver && m.showWin {
+		return m.renderGameOver() + minesweeperHelpStyle.Render("R 重新开始 | Q 返回大厅")
+	}
+
+	// 帮助信息
+	help := "方向键移动 | 空格/Enter 翻开 | F 标记 | C 连开 | T 提示 | P 暂停 | Ctrl+S 保存 | Q 退出"
+	if m.game.Paused() {
+		help = "P 继续 | Ctrl+S 保存 | Q 退出"
+	}
+	return m.renderBoard() + minesweeperHelpStyle.Render(help)
+}
+
+// render 渲染当前局面，不包含按键帮助，回放时也使用它
+func (m *MinesweeperModel) render() string {
 	if m.game.GameOver && m.showWin {
 		return m.renderGameOver()
 	}
+	return m.renderBoard()
+}
+
+// elapsed 返回显示的用时，回放时使用录像中记录的时间
+func (m *MinesweeperModel) elapsed() time.Duration {
+	if m.replaying {
+		return m.replayTime
+	}
+	return m.game.GetElapsedTime()
+}
+
+// reveal 翻开光标处的格子，在已翻开的数字上翻开等同于连开
+func (m *MinesweeperModel) reveal() {
+	if m.game.Grid[m.cursorY][m.cursorX].State == game.CellRevealed {
+		m.game.Chord(m.cursorX, m.cursorY)
+	} else {
+		m.game.Reveal(m.cursorX, m.cursorY)
+	}
+	m.finish()
+}
+
+// showHint 标出一个一定安全的格子，没有时标出是雷的概率最低的格子。
+// 雷区没有变化时再次提示不重复计数。
+func (m *MinesweeperModel) showHint() {
+	if m.hintActive() {
+		m.status = m.hintStatus()
+		return
+	}
+
+	var p game.Point
+	var prob float64
+	if !m.game.MinesPlaced {
+		// 第一次翻开的格子总是安全的，建议从中间开始
+		p = game.Point{X: m.game.Width / 2, Y: m.game.Height / 2}
+	} else {
+		var ok bool
+		p, prob, ok = game.NewSolver(m.game).Hint()
+		if !ok {
+			m.status = "没有可以提示的格子"
+			return
+		}
+	}
+
+	m.hint, m.hintProb, m.hintAt = &p, prob, len(m.game.Actions)
+	m.hints++
+	m.status = m.hintStatus()
+}
+
+// hintActive 报告提示的格子是否仍然有效，翻开或标记之后提示就失效了
+func (m *MinesweeperModel) hintActive() bool {
+	return m.hint != nil && !m.game.GameOver && len(m.game.Actions) == m.hintAt
+}
+
+func (m *MinesweeperModel) hintStatus() string {
+	where := fmt.Sprintf("💡 (%d, %d) ", m.hint.X+1, m.hint.Y+1)
+	if m.hintProb == 0 {
+		return where + "一定安全"
+	}
+	return where + fmt.Sprintf("是雷的概率 %.0f%%", m.hintProb*100)
+}
+
+// updateMouse 处理鼠标点击：左键翻开，右键标记，中键或者左右键同时按下时连开
+func (m *MinesweeperModel) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if msg.Action == tea.MouseActionRelease {
+		// 有的终端松开时不报告是哪个键，当作两个键都松开了
+		switch msg.Button {
+		case tea.MouseButtonLeft:
+			m.leftDown = false
+		case tea.MouseButtonRight:
+			m.rightDown = false
+		default:
+			m.leftDown, m.rightDown = false, false
+		}
+		return m, nil
+	}
+	if msg.Action != tea.MouseActionPress || m.game.GameOver || m.game.Paused() {
+		return m, nil
+	}
+	x, y, ok := m.cellAt(msg.X, msg.Y)
+	if !ok {
+		return m, nil
+	}
+
+	m.status = ""
+	m.cursorX, m.cursorY = x, y
+	switch msg.Button {
+	case tea.MouseButtonLeft:
+		m.leftDown = true
+		if m.rightDown {
+			m.chord()
+		} else {
+			m.reveal()
+		}
+	case tea.MouseButtonRight:
+		m.rightDown = true
+		if m.leftDown {
+			m.chord()
+		} else {
+			m.game.ToggleFlag(x, y)
+		}
+	case tea.MouseButtonMiddle:
+		m.chord()
+	}
+	return m, m.schedule()
+}
 
+// chord 在光标处连开
+func (m *MinesweeperModel) chord() {
+	m.game.Chord(m.cursorX, m.cursorY)
+	m.finish()
+}
+
+// cellAt 把终端坐标换算成格子坐标。雷区位于信息栏下方，信息栏在窄终端中
+// 会折成多行，雷区外面有一圈边框，每个格子占 3 列
+func (m *MinesweeperModel) cellAt(col, row int) (x, y int, ok bool) {
+	top := lipgloss.Height(m.renderInfo())
+	if col < 1 || row < top {
+		return 0, 0, false
+	}
+	x, y = (col-1)/3, row-top
+	if x >= m.game.Width || y >= m.game.Height {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+func (m *MinesweeperModel) renderBoard() string {
 	var b strings.Builder
 
-	// 游戏信息
-	elapsed := m.game.GetElapsedTime()
-	info := fmt.Sprintf("雷数: %d | 标记: %d | 时间: %d秒",
-		m.game.MineCount,
-		m.game.Flags,
-		int(elapsed.Seconds()))
+	b.WriteString(m.renderInfo())
 
-	b.WriteString(minesweeperInfoStyle.Render(info))
-	b.WriteString("\n\n")
+	// 暂停时隐藏雷区，只保留它的大小
+	if m.game.Paused() {
+		paused := lipgloss.Place(m.game.Width*3, m.game.Height,
+			lipgloss.Center, lipgloss.Center, pausedStyle.Render("⏸ 已暂停"))
+		b.WriteString(borderStyle.Render(paused))
+		b.WriteString("\n\n")
+		return b.String()
+	}
 
 	// 游戏网格
 	grid := make([]string, m.game.Height)
@@ -157,6 +659,9 @@ func (m *MinesweeperModel) View() string {
 				case game.CellFlagged:
 					style = cursorStyle.Copy().Background(lipgloss.Color("202"))
 					cellStr = "🚩"
+				case game.CellQuestion:
+					style = cursorStyle
+					cellStr = "❓"
 				case game.CellRevealed:
 					// 已解开的区域使用特殊的光标样式
 					style = cursorRevealedStyle.Copy()
@@ -181,14 +686,20 @@ func (m *MinesweeperModel) View() string {
 					cellStr = "?"
 				}
 			} else {
-				switch cell.State {
-				case game.CellHidden:
+				switch {
+				case m.hintActive() && *m.hint == game.Point{X: x, Y: y}:
+					style = hintCellStyle
+					cellStr = "💡"
+				case cell.State == game.CellHidden:
 					style = hiddenStyle
 					cellStr = " "
-				case game.CellFlagged:
+				case cell.State == game.CellFlagged:
 					style = flagStyle
 					cellStr = "🚩"
-				case game.CellRevealed:
+				case cell.State == game.CellQuestion:
+					style = questionStyle
+					cellStr = "❓"
+				case cell.State == game.CellRevealed:
 					if cell.IsMine {
 						style = mineStyle
 						cellStr = "💣"
@@ -210,13 +721,38 @@ func (m *MinesweeperModel) View() string {
 	b.WriteString(borderStyle.Render(strings.Join(grid, "\n")))
 	b.WriteString("\n\n")
 
-	// 帮助信息
-	help := "方向键移动 | 空格/Enter 翻开 | F 标记 | R 重玩 | Q 退出"
-	b.WriteString(minesweeperHelpStyle.Render(help))
-
 	return b.String()
 }
 
+// renderInfo 渲染雷区上方的信息栏
+func (m *MinesweeperModel) renderInfo() string {
+	elapsed := m.elapsed()
+	info := fmt.Sprintf("%s | 雷数: %d | 标记: %d | 时间: %d秒 | 种子: %d",
+		difficultyNames[m.difficulty],
+		m.game.MineCount,
+		m.game.Flags,
+		int(elapsed.Seconds()),
+		m.game.Seed)
+
+	if m.daily != "" {
+		info = "📅 每日挑战 " + m.daily + " | " + info
+	}
+	switch {
+	case m.game.NoGuessFailed:
+		info += " | 没能生成无需猜测的雷区，可能需要猜测"
+	case m.noGuess:
+		info += " | 无需猜测"
+	}
+	if m.status != "" {
+		info += " | " + m.status
+	}
+	style := minesweeperInfoStyle
+	if m.width > 0 {
+		style = style.Width(m.width)
+	}
+	return style.Render(info) + "\n\n"
+}
+
 func (m *MinesweeperModel) getCellContent(cell game.Cell) string {
 	if cell.IsMine {
 		return "💣"
@@ -285,18 +821,23 @@ func (m *MinesweeperModel) renderGameOver() string {
 			var style lipgloss.Style
 
 			if cell.IsMine {
-				if cell.State == game.CellFlagged {
+				switch {
+				case cell.State == game.CellFlagged:
 					style = flagStyle
 					cellStr = "🚩"
-				} else {
+				case slices.Contains(m.game.Exploded, game.Point{X: x, Y: y}):
+					style = explodedStyle
+					cellStr = "💥"
+				default:
 					style = mineStyle
 					cellStr = "💣"
 				}
 			} else {
 				switch cell.State {
 				case game.CellFlagged:
-					style = flagStyle
-					cellStr = "🚩"
+					// 标错的旗子
+					style = wrongFlagStyle
+					cellStr = "❌"
 				default:
 					style = revealedStyle
 					cellStr = m.getCellContent(cell)
@@ -311,13 +852,16 @@ func (m *MinesweeperModel) renderGameOver() string {
 	b.WriteString(borderStyle.Render(strings.Join(grid, "\n")))
 	b.WriteString("\n\n")
 
-	elapsed := m.game.GetElapsedTime()
-	stats := fmt.Sprintf("用时: %d秒", int(elapsed.Seconds()))
+	elapsed := m.elapsed()
+	stats := fmt.Sprintf("用时: %d秒 | 种子: %d", int(elapsed.Seconds()), m.game.Seed)
+	if m.hints > 0 {
+		stats += fmt.Sprintf(" | 提示: %d 次", m.hints)
+	}
+	if m.replayID != "" {
+		stats += " | 录像: " + m.replayID
+	}
 	b.WriteString(minesweeperInfoStyle.Render(stats))
 	b.WriteString("\n\n")
 
-	help := "R 重新开始 | Q 返回大厅"
-	b.WriteString(minesweeperHelpStyle.Render(help))
-
 	return b.String()
 }
@@ -0,0 +1,352 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"termiplay/go-backend/game"
+	"termiplay/go-backend/registry"
+	"termiplay/go-backend/store"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// maxReplayDelay 限制两步操作之间的等待，玩家长时间思考或离线时不必干等
+const maxReplayDelay = 2 * time.Second
+
+// replaySpeeds 是可选的回放倍速
+var replaySpeeds = []float64{0.5, 1, 2, 4, 8}
+
+var (
+	replayStatusStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("39")).
+				Bold(true)
+
+	replayHelpStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241")).
+			MarginTop(1)
+)
+
+// actionPlayback 按操作序列回放一局游戏，具体的游戏只需要提供
+// 重新开局、执行操作和渲染三个函数。创建后需要先调用 Rewind
+type actionPlayback struct {
+	actions []game.Action
+	pos     int
+	reset   func()
+	apply   func(a game.Action)
+	view    func() string
+}
+
+func (p *actionPlayback) Len() int { return len(p.actions) }
+func (p *actionPlayback) Pos() int { return p.pos }
+
+func (p *actionPlayback) Step() bool {
+	if p.pos >= len(p.actions) {
+		return false
+	}
+	p.apply(p.actions[p.pos])
+	p.pos++
+	return true
+}
+
+func (p *actionPlayback) Rewind() {
+	p.reset()
+	p.pos = 0
+}
+
+func (p *actionPlayback) Delay() time.Duration {
+	if p.pos >= len(p.actions) {
+		return 0
+	}
+	d := p.actions[p.pos].At
+	if p.pos > 0 {
+		d -= p.actions[p.pos-1].At
+	}
+	return max(0, min(d, maxReplayDelay))
+}
+
+func (p *actionPlayback) View() string {
+	return p.view()
+}
+
+// replayTickMsg 驱动自动播放，id 用来丢弃暂停之前安排的过期消息
+type replayTickMsg struct{ id int }
+
+// ReplayModel 播放一段录像，支持播放/暂停、单步和调整速度
+type ReplayModel struct {
+	playback registry.Playback
+	title    string
+	playing  bool
+	speed    int // replaySpeeds 中的下标
+	tickID   int
+	back     tea.Model // 退出回放后返回的界面，为空时返回大厅
+}
+
+func NewReplayModel(r store.Replay, back tea.Model) (*ReplayModel, error) {
+	g, ok := registry.Lookup(r.Game)
+	if !ok {
+		return nil, fmt.Errorf("未知的游戏 %q", r.Game)
+	}
+	rg, ok := g.(registry.ReplayableGame)
+	if !ok {
+		return nil, fmt.Errorf("%s 不支持回放", g.Name())
+	}
+	p, err := rg.NewPlayback(r.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	title := fmt.Sprintf("🎬 %s 的 %s", r.Nickname, g.Name())
+	if v := variantName(r.Result); v != "" {
+		title += " (" + v + ")"
+	}
+	return &ReplayModel{
+		playback: p,
+		title:    title,
+		playing:  true,
+		speed:    1,
+		back:     back,
+	}, nil
+}
+
+func (m *ReplayModel) Init() tea.Cmd {
+	return m.schedule()
+}
+
+// schedule 安排下一步的自动播放
+func (m *ReplayModel) schedule() tea.Cmd {
+	m.tickID++
+	if !m.playing || m.playback.Pos() >= m.playback.Len() {
+		m.playing = false
+		return nil
+	}
+	id := m.tickID
+	d := time.Duration(float64(m.playback.Delay()) / replaySpeeds[m.speed])
+	return tea.Tick(d, func(time.Time) tea.Msg { return replayTickMsg{id: id} })
+}
+
+func (m *ReplayModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case replayTickMsg:
+		if msg.id != m.tickID {
+			return m, nil
+		}
+		m.playback.Step()
+		return m, m.schedule()
+	case tea.KeyMsg:
+		switch msg.String() {
+		case " ", "p":
+			if m.playback.Pos() >= m.playback.Len() {
+				m.playback.Rewind()
+			}
+			m.playing = !m.playing
+			return m, m.schedule()
+		case "right", "l", "n":
+			m.playing = false
+			m.playback.Step()
+			return m, m.schedule()
+		case "r":
+			m.playback.Rewind()
+			return m, m.schedule()
+		case "+", "=":
+			if m.speed < len(replaySpeeds)-1 {
+				m.speed++
+			}
+			return m, m.schedule()
+		case "-":
+			if m.speed > 0 {
+				m.speed--
+			}
+			return m, m.schedule()
+		case "q", "esc":
+			m.tickID++
+			if m.back != nil {
+				return m.back, nil
+			}
+			return m, registry.Exit
+		}
+	}
+	return m, nil
+}
+
+func (m *ReplayModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(m.title))
+	b.WriteString("\n\n")
+	b.WriteString(m.playback.View())
+
+	state := "⏸ 暂停"
+	if m.playing {
+		state = "▶ 播放中"
+	} else if m.playback.Pos() >= m.playback.Len() {
+		state = "⏹ 已结束"
+	}
+	b.WriteString(replayStatusStyle.Render(fmt.Sprintf("%s | 第 %d/%d 步 | %gx",
+		state, m.playback.Pos(), m.playback.Len(), replaySpeeds[m.speed])))
+	b.WriteString("\n")
+	b.WriteString(replayHelpStyle.Render("空格 播放/暂停 | → 单步 | +/- 速度 | R 从头播放 | Q 返回"))
+
+	return b.String()
+}
+
+// dailySpoiler 返回一个报告录像是否需要对玩家隐藏的函数。每日挑战的录像
+// 会暴露所有人共用的雷区或出块顺序：当天的录像要等到第二天，或者玩家自己完成了
+// 当天的这个游戏才能观看；玩家还有某一天没完成的存档时，那一天的录像也不能
+// 观看，否则可以先开局，过了午夜看完别人的录像再继续
+func dailySpoiler(s *registry.Session) func(store.Replay) bool {
+	today := game.DailyKey(time.Now())
+	done := make(map[string]bool)
+	unfinished := make(map[string]bool) // 游戏 ID + "/" + 日期
+	if !s.Player.IsGuest() {
+		results, err := s.Store.Results(func(r store.Result) bool {
+			return r.PlayerID == s.Player.ID && r.Daily == today
+		})
+		if err != nil {
+			log.Error("Could not load daily results", "player", s.Player.ID, "error", err)
+		}
+		for _, r := range results {
+			done[r.Game] = true
+		}
+
+		saves, err := s.Store.Saves(s.Player.ID)
+		if err != nil {
+			log.Error("Could not load saves", "player", s.Player.ID, "error", err)
+		}
+		for _, sv := range saves {
+			if sv.Daily != "" {
+				unfinished[sv.Game+"/"+sv.Daily] = true
+			}
+		}
+	}
+	return func(r store.Replay) bool {
+		if r.Daily == "" {
+			return false
+		}
+		return r.Daily == today && !done[r.Game] || unfinished[r.Game+"/"+r.Daily]
+	}
+}
+
+// ReplayHidden 报告玩家现在是否还不能观看录像 r，见 dailySpoiler
+func ReplayHidden(s *registry.Session, r store.Replay) bool {
+	return dailySpoiler(s)(r)
+}
+
+// replayListSize 是录像列表显示的条数
+const replayListSize = 20
+
+// ReplaysModel 列出最近的录像
+type ReplaysModel struct {
+	session *registry.Session
+	mine    bool // 只显示自己的录像
+	replays []store.Replay
+	cursor  int
+	err     string
+}
+
+func NewReplaysModel(s *registry.Session) *ReplaysModel {
+	m := &ReplaysModel{session: s}
+	m.load()
+	return m
+}
+
+func (m *ReplaysModel) load() {
+	hidden := dailySpoiler(m.session)
+	replays, err := m.session.Store.Replays(func(r store.Replay) bool {
+		return (!m.mine || r.PlayerID == m.session.Player.ID) && !hidden(r)
+	})
+	if err != nil {
+		m.err = "读取录像失败: " + err.Error()
+	}
+	m.replays = replays[:min(len(replays), replayListSize)]
+	m.cursor = 0
+}
+
+func (m *ReplaysModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *ReplaysModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		m.err = ""
+		switch msg.String() {
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.replays)-1 {
+				m.cursor++
+			}
+		case "tab":
+			m.mine = !m.mine
+			m.load()
+		case "enter", " ":
+			if len(m.replays) == 0 {
+				return m, nil
+			}
+			replay, err := NewReplayModel(m.replays[m.cursor], m)
+			if err != nil {
+				m.err = "无法播放: " + err.Error()
+				return m, nil
+			}
+			// appModel 切换界面时会调用 Init 开始播放
+			return replay, nil
+		case "q", "esc":
+			return m, registry.Exit
+		}
+	}
+	return m, nil
+}
+
+func (m *ReplaysModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("🎬 录像"))
+	b.WriteString("\n\n")
+
+	scope := "全部玩家"
+	if m.mine {
+		scope = "只看自己"
+	}
+	b.WriteString(playerStyle.Render(scope))
+	b.WriteString("\n\n")
+
+	if len(m.replays) == 0 {
+		b.WriteString(descriptionStyle.Render("还没有录像"))
+		b.WriteString("\n")
+	}
+	for i, r := range m.replays {
+		cursor := " "
+		style := menuItemStyle
+		if m.cursor == i {
+			cursor = ">"
+			style = selectedStyle
+		}
+
+		name := r.Game
+		if g, ok := registry.Lookup(r.Game); ok {
+			name = g.Name()
+		}
+		if v := variantName(r.Result); v != "" {
+			name += " " + v
+		}
+		line := nameColumnStyle.Render(r.Nickname) +
+			nameColumnStyle.Render(name) +
+			valueColumnStyle.Render(resultSummary(r.Result)) +
+			r.FinishedAt.Format("01-02 15:04") + "  " + descriptionStyle.Render(r.ID)
+		b.WriteString(cursor + " " + style.Render(line) + "\n")
+	}
+
+	if m.err != "" {
+		b.WriteString("\n" + errorStyle.Render(m.err) + "\n")
+	}
+
+	b.WriteString(lobbyHelpStyle.Render("↑/↓ 选择 | Enter 播放 | Tab 全部/自己 | Q 返回大厅"))
+
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"termiplay/go-backend/game"
+	"termiplay/go-backend/registry"
+	"termiplay/go-backend/store"
+
+	"github.com/charmbracelet/log"
+)
+
+// recordResult 以会话中玩家的名义保存一局游戏的结果和录像，返回录像编号
+func recordResult(s *registry.Session, r store.Result, replay any) string {
+	r.PlayerID = s.Player.ID
+	r.Nickname = s.Player.DisplayName()
+	r.FinishedAt = time.Now()
+	if err := s.Store.AddResult(r); err != nil {
+		log.Error("Could not save result", "game", r.Game, "error", err)
+	}
+
+	data, err := json.Marshal(replay)
+	if err != nil {
+		log.Error("Could not encode replay", "game", r.Game, "error", err)
+		return ""
+	}
+	id, err := s.Store.AddReplay(store.Replay{Result: r, Data: data})
+	if err != nil {
+		log.Error("Could not save replay", "game", r.Game, "error", err)
+	}
+	return id
+}
+
+// resultSummary 用一句话概括一局游戏的结果
+func resultSummary(r store.Result) string {
+	switch {
+	case r.Score > 0:
+		return fmt.Sprintf("%d 分", r.Score)
+	case r.Won:
+		return fmt.Sprintf("胜利 %.1f秒", r.Duration.Seconds())
+	default:
+		return "失败"
+	}
+}
+
+// variantName 返回对局变体的显示名称
+func variantName(r store.Result) string {
+	if r.Game == (MinesweeperGame{}).ID() {
+		if cfg, ok := strings.CutPrefix(r.Variant, customVariantPrefix); ok {
+			return difficultyNames[game.Custom] + " " + cfg
+		}
+		for d, name := range difficultyNames {
+			if d.String() == r.Variant {
+				return name
+			}
+		}
+	}
+	return r.Variant
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"termiplay/go-backend/registry"
+	"termiplay/go-backend/store"
+
+	"github.com/charmbracelet/log"
+)
+
+var errGuestSave = errors.New("游客无法存档，请使用公钥登录")
+
+// saveGame 把 state 编码后保存为玩家在 game 中的存档
+func saveGame(s *registry.Session, game, daily string, state any) error {
+	if s.Player.IsGuest() {
+		return errGuestSave
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.Store.PutSave(s.Player.ID, store.Save{
+		Game:    game,
+		Daily:   daily,
+		Data:    data,
+		SavedAt: time.Now(),
+	})
+}
+
+// deleteSave 在对局结束后删除对应的存档
+func deleteSave(s *registry.Session, game, daily string) {
+	if err := s.Store.DeleteSave(s.Player.ID, game, daily); err != nil {
+		log.Error("Could not delete save", "game", game, "error", err)
+	}
+}
+
+// saveStatus 返回保存后显示给玩家的提示
+func saveStatus(err error) string {
+	if err != nil {
+		return "保存失败: " + err.Error()
+	}
+	return "💾 已保存"
+}
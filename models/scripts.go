@@ -0,0 +1,239 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"termiplay/go-backend/bot"
+	"termiplay/go-backend/registry"
+	"termiplay/go-backend/store"
+)
+
+// Script 是不需要终端的命令，例如 ssh host scores --json。
+// 结果以纯文本或 JSON 写到 out，写完连接就结束了，方便在脚本中使用。
+// 纯文本每行一条记录，各列之间用制表符分隔，第一行是列名。
+type Script struct {
+	Name        string
+	Usage       string // 参数的简短说明
+	Description string
+	Run         func(s *registry.Session, args []string, in io.Reader, out io.Writer) error
+}
+
+// Scripts 是所有可以在没有终端时执行的命令
+var Scripts = []Script{
+	{"scores", "[--json]", "各个排行榜的前 10 名", runScores},
+	{"stats", "me [--json]", "自己每种游戏的统计", runStats},
+	{"export-replays", "[--all]", "以 JSON Lines 导出自己的录像，--all 导出所有人的", runExportReplays},
+	{"bot", bot.Usage, "用按行的 JSON 协议玩一局，不计入成绩", runBot},
+}
+
+// LookupScript 按名称查找命令
+func LookupScript(name string) (Script, bool) {
+	i := slices.IndexFunc(Scripts, func(c Script) bool { return c.Name == name })
+	if i < 0 {
+		return Script{}, false
+	}
+	return Scripts[i], true
+}
+
+// scriptFlags 解析只由开关组成的参数，返回每个开关是否出现
+func scriptFlags(args []string, names ...string) (map[string]bool, error) {
+	set := make(map[string]bool)
+	for _, a := range args {
+		if !slices.Contains(names, a) {
+			return nil, fmt.Errorf("未知的参数 %q", a)
+		}
+		set[a] = true
+	}
+	return set, nil
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeRows 把 rows 写成以制表符分隔的纯文本
+func writeRows(w io.Writer, rows [][]string) error {
+	for _, r := range rows {
+		if _, err := fmt.Fprintln(w, strings.Join(r, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scoreBoard 是 scores --json 输出的一个榜单
+type scoreBoard struct {
+	Title   string         `json:"title"`
+	Game    string         `json:"game"`
+	Variant string         `json:"variant,omitempty"`
+	Fastest bool           `json:"fastest"`
+	Entries []store.Result `json:"entries"`
+}
+
+func runScores(s *registry.Session, args []string, _ io.Reader, w io.Writer) error {
+	flags, err := scriptFlags(args, "--json")
+	if err != nil {
+		return err
+	}
+
+	var boards []scoreBoard
+	for _, t := range leaderboardTabs(s) {
+		entries, err := s.Store.Leaderboard(t.board, leaderboardSize)
+		if err != nil {
+			return fmt.Errorf("读取排行榜失败: %w", err)
+		}
+		boards = append(boards, scoreBoard{
+			Title:   t.title,
+			Game:    t.board.Game,
+			Variant: t.board.Variant,
+			Fastest: t.board.Fastest,
+			Entries: entries,
+		})
+	}
+	if flags["--json"] {
+		return writeJSON(w, boards)
+	}
+
+	rows := [][]string{{"榜单", "名次", "玩家", "成绩", "日期"}}
+	for _, b := range boards {
+		for i, r := range b.Entries {
+			value := fmt.Sprintf("%d", r.Score)
+			if b.Fastest {
+				value = fmt.Sprintf("%.1f", r.Duration.Seconds())
+			}
+			rows = append(rows, []string{b.Title, fmt.Sprint(i + 1), r.Nickname, value, r.FinishedAt.Format("2006-01-02")})
+		}
+	}
+	return writeRows(w, rows)
+}
+
+// gameStats 是玩家在一种游戏设置下的统计
+type gameStats struct {
+	Game       string        `json:"game"`
+	Variant    string        `json:"variant,omitempty"`
+	Played     int           `json:"played"`
+	Won        int           `json:"won"`
+	BestScore  int           `json:"best_score,omitempty"`
+	FastestWin time.Duration `json:"fastest_win,omitempty"`
+	PlayTime   time.Duration `json:"play_time"`
+}
+
+// playerStats 是 stats me --json 的输出
+type playerStats struct {
+	PlayerID string      `json:"player_id"`
+	Nickname string      `json:"nickname"`
+	Games    []gameStats `json:"games"`
+}
+
+func runStats(s *registry.Session, args []string, _ io.Reader, w io.Writer) error {
+	if len(args) == 0 || args[0] != "me" {
+		return errors.New("用法: stats me [--json]")
+	}
+	flags, err := scriptFlags(args[1:], "--json")
+	if err != nil {
+		return err
+	}
+	if s.Player.IsGuest() {
+		return errors.New("游客没有统计，请使用公钥登录")
+	}
+
+	results, err := s.Store.Results(func(r store.Result) bool { return r.PlayerID == s.Player.ID })
+	if err != nil {
+		return fmt.Errorf("读取成绩失败: %w", err)
+	}
+	stats := playerStats{PlayerID: s.Player.ID, Nickname: s.Player.DisplayName(), Games: []gameStats{}}
+	for _, r := range results {
+		i := slices.IndexFunc(stats.Games, func(g gameStats) bool {
+			return g.Game == r.Game && g.Variant == r.Variant
+		})
+		if i < 0 {
+			stats.Games = append(stats.Games, gameStats{Game: r.Game, Variant: r.Variant})
+			i = len(stats.Games) - 1
+		}
+		g := &stats.Games[i]
+		g.Played++
+		g.PlayTime += r.Duration
+		g.BestScore = max(g.BestScore, r.Score)
+		if r.Won {
+			g.Won++
+			if g.FastestWin == 0 || r.Duration < g.FastestWin {
+				g.FastestWin = r.Duration
+			}
+		}
+	}
+	slices.SortFunc(stats.Games, func(a, b gameStats) int {
+		return strings.Compare(a.Game+"/"+a.Variant, b.Game+"/"+b.Variant)
+	})
+	if flags["--json"] {
+		return writeJSON(w, stats)
+	}
+
+	rows := [][]string{{"游戏", "设置", "局数", "胜利", "最高分", "最快胜利", "总用时"}}
+	for _, g := range stats.Games {
+		name := g.Game
+		if game, ok := registry.Lookup(g.Game); ok {
+			name = game.Name()
+		}
+		best, fastest := "", ""
+		if g.BestScore > 0 {
+			best = fmt.Sprint(g.BestScore)
+		}
+		if g.FastestWin > 0 {
+			fastest = fmt.Sprintf("%.1f", g.FastestWin.Seconds())
+		}
+		rows = append(rows, []string{
+			name,
+			variantName(store.Result{Game: g.Game, Variant: g.Variant}),
+			fmt.Sprint(g.Played),
+			fmt.Sprint(g.Won),
+			best,
+			fastest,
+			g.PlayTime.Round(time.Second).String(),
+		})
+	}
+	return writeRows(w, rows)
+}
+
+func runExportReplays(s *registry.Session, args []string, _ io.Reader, w io.Writer) error {
+	flags, err := scriptFlags(args, "--all")
+	if err != nil {
+		return err
+	}
+	all := flags["--all"]
+	if !all && s.Player.IsGuest() {
+		return errors.New("游客没有录像，请使用公钥登录，或者用 --all 导出所有人的录像")
+	}
+
+	hidden := dailySpoiler(s)
+	replays, err := s.Store.Replays(func(r store.Replay) bool {
+		return (all || r.PlayerID == s.Player.ID) && !hidden(r)
+	})
+	if err != nil {
+		return fmt.Errorf("读取录像失败: %w", err)
+	}
+	enc := json.NewEncoder(w)
+	for _, r := range replays {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runBot 只开放大厅中启用的游戏
+func runBot(_ *registry.Session, args []string, in io.Reader, out io.Writer) error {
+	if len(args) > 0 {
+		if _, ok := registry.Lookup(args[0]); !ok {
+			return fmt.Errorf("未知的游戏 %q", args[0])
+		}
+	}
+	return bot.Run(args, in, out)
+}
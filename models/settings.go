@@ -0,0 +1,108 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"termiplay/go-backend/player"
+	"termiplay/go-backend/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// setting 是设置界面中的一个开关
+type setting struct {
+	name        string
+	description string
+	value       func(s *player.Settings) *bool
+}
+
+var settings = []setting{
+	{
+		name:        "扫雷问号标记",
+		description: "标记时在 旗子 → 问号 → 取消 之间循环",
+		value:       func(s *player.Settings) *bool { return &s.QuestionMarks },
+	},
+	{
+		name:        "减少动画",
+		description: "关闭 2048 的方块动画，适合较慢的网络",
+		value:       func(s *player.Settings) *bool { return &s.ReduceMotion },
+	},
+}
+
+// SettingsModel 让玩家修改个人设置，游客的设置只在本次连接中有效
+type SettingsModel struct {
+	session *registry.Session
+	cursor  int
+	err     string
+}
+
+func NewSettingsModel(s *registry.Session) *SettingsModel {
+	return &SettingsModel{session: s}
+}
+
+func (m *SettingsModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *SettingsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		m.err = ""
+		switch msg.String() {
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(settings)-1 {
+				m.cursor++
+			}
+		case "enter", " ":
+			p := m.session.Player
+			v := settings[m.cursor].value(&p.Settings)
+			*v = !*v
+			if err := m.session.Store.SavePlayer(p); err != nil {
+				m.err = "保存失败: " + err.Error()
+				return m, nil
+			}
+			m.session.Player = p
+		case "q", "esc":
+			return m, registry.Exit
+		}
+	}
+	return m, nil
+}
+
+func (m *SettingsModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("⚙️ 设置"))
+	b.WriteString("\n\n")
+
+	for i, st := range settings {
+		cursor := " "
+		style := menuItemStyle
+		if m.cursor == i {
+			cursor = ">"
+			style = selectedStyle
+		}
+
+		value := descriptionStyle.Render("关")
+		if *st.value(&m.session.Player.Settings) {
+			value = doneStyle.Render("开")
+		}
+		b.WriteString(fmt.Sprintf("%s %s %s\n", cursor, style.Render(st.name), value))
+		b.WriteString(fmt.Sprintf("    %s\n", descriptionStyle.Render(st.description)))
+	}
+
+	if m.session.Player.IsGuest() {
+		b.WriteString("\n" + descriptionStyle.Render("游客的设置不会被保存") + "\n")
+	}
+	if m.err != "" {
+		b.WriteString("\n" + errorStyle.Render(m.err) + "\n")
+	}
+
+	b.WriteString(lobbyHelpStyle.Render("↑/↓ 选择 | Enter 切换 | Q 返回大厅"))
+	return b.String()
+}
@@ -0,0 +1,79 @@
+// Package player 描述连接到服务器的玩家身份。
+package player
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// GuestName 是没有使用公钥登录的玩家显示的名字。
+const GuestName = "游客"
+
+// MaxNicknameLength 是昵称允许的最大字符数。
+const MaxNicknameLength = 16
+
+// Player 是一名玩家，用公钥指纹作为稳定的身份。
+type Player struct {
+	ID        string    `json:"id"` // 公钥的 SHA256 指纹，游客为空
+	Nickname  string    `json:"nickname"`
+	CreatedAt time.Time `json:"created_at"`
+	Settings  Settings  `json:"settings"`
+}
+
+// Settings 是玩家的个人设置，零值是默认设置。
+type Settings struct {
+	// QuestionMarks 为 true 时扫雷的标记在旗子和问号之间循环
+	QuestionMarks bool `json:"question_marks"`
+	// ReduceMotion 为 true 时关闭 2048 的方块动画，适合较慢的网络
+	ReduceMotion bool `json:"reduce_motion"`
+}
+
+// FromSession 根据 SSH 会话使用的公钥得到玩家身份。
+func FromSession(s ssh.Session) Player {
+	key := s.PublicKey()
+	if key == nil {
+		return Player{}
+	}
+	return Player{ID: gossh.FingerprintSHA256(key)}
+}
+
+// IsGuest 报告玩家是否没有公钥身份。
+func (p Player) IsGuest() bool {
+	return p.ID == ""
+}
+
+// NeedsNickname 报告玩家是否是第一次登录、还没有选择昵称。
+func (p Player) NeedsNickname() bool {
+	return !p.IsGuest() && p.Nickname == ""
+}
+
+// DisplayName 返回用于显示的名字。
+func (p Player) DisplayName() string {
+	if p.Nickname == "" {
+		return GuestName
+	}
+	return p.Nickname
+}
+
+// ValidateNickname 检查昵称是否可用。
+func ValidateNickname(name string) error {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return errors.New("昵称不能为空")
+	case utf8.RuneCountInString(name) > MaxNicknameLength:
+		return errors.New("昵称太长")
+	case name == GuestName:
+		return errors.New("这个昵称不可用")
+	case strings.IndexFunc(name, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0:
+		// 控制字符会在其他玩家的终端里执行，制表符和换行会弄乱脚本的输出
+		return errors.New("昵称不能包含控制字符")
+	}
+	return nil
+}
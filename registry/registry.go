@@ -0,0 +1,173 @@
+// Package registry 维护大厅中可选择的游戏列表。
+//
+// 每个游戏通过实现 Game 接口并在 init 中调用 Register 注册自己，服务器启动时
+// 用 Enable 按配置选出大厅中列出的游戏和它们的顺序，appModel 通过 Game.New
+// 创建对应的模型。
+package registry
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"termiplay/go-backend/player"
+	"termiplay/go-backend/store"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Session 是一次 SSH 连接的上下文，会传给该连接中创建的每个模型。
+type Session struct {
+	Player player.Player
+	Store  *store.Store
+}
+
+// Game 描述一个可以在大厅中启动的游戏。
+type Game interface {
+	// ID 返回唯一标识，例如 "minesweeper"。
+	ID() string
+	// Name 返回在大厅中显示的名称。
+	Name() string
+	// Description 返回一句话简介。
+	Description() string
+	// New 为会话 s 中的玩家创建一局新游戏。
+	New(s *Session) tea.Model
+}
+
+// DailyGame 由支持每日挑战的游戏实现。
+type DailyGame interface {
+	Game
+	// NewDaily 创建日期 day 的每日挑战，同一天所有玩家的棋局相同。
+	NewDaily(s *Session, day string) tea.Model
+}
+
+// ArgsGame 由可以通过 SSH 命令的参数直接开局的游戏实现，
+// 例如 ssh host minesweeper --hard。
+type ArgsGame interface {
+	Game
+	// NewWithArgs 按参数 args 创建一局新游戏，args 不为空，参数无效时返回错误。
+	NewWithArgs(s *Session, args []string) (tea.Model, error)
+	// Usage 返回参数的简短说明，例如 "[--easy | --medium | --hard]"。
+	Usage() string
+}
+
+// ResumableGame 由可以从存档恢复的游戏实现。
+type ResumableGame interface {
+	Game
+	// Resume 从 Resumable.Save 写入的存档数据恢复对局。
+	Resume(s *Session, data []byte) (tea.Model, error)
+}
+
+// Resumable 由可以存档的游戏模型实现。
+type Resumable interface {
+	// Save 把进行中的对局写入玩家的存档，对局已经结束时什么也不做。
+	Save() error
+}
+
+// ReplayableGame 由可以回放录像的游戏实现。
+type ReplayableGame interface {
+	Game
+	// NewPlayback 从录像数据创建一个回放。
+	NewPlayback(data []byte) (Playback, error)
+}
+
+// Playback 逐步重现一局录下来的游戏。
+type Playback interface {
+	// Len 返回录像中的操作数。
+	Len() int
+	// Pos 返回已经执行的操作数。
+	Pos() int
+	// Step 执行下一个操作，没有更多操作时返回 false。
+	Step() bool
+	// Rewind 回到开局。
+	Rewind()
+	// Delay 返回下一个操作与上一个操作之间的时间间隔。
+	Delay() time.Duration
+	// View 使用游戏自己的渲染方式显示当前局面。
+	View() string
+}
+
+// LaunchMsg 请求启动指定的游戏。
+type LaunchMsg struct {
+	Game Game
+}
+
+// Launch 返回启动游戏 g 的命令。
+func Launch(g Game) tea.Cmd {
+	return func() tea.Msg { return LaunchMsg{Game: g} }
+}
+
+// OpenMsg 请求切换到一个不属于任何游戏的界面，例如排行榜。
+type OpenMsg struct {
+	Model tea.Model
+}
+
+// ExitMsg 由游戏模型或其他界面发出，表示玩家想要返回大厅。
+type ExitMsg struct{}
+
+// Exit 是请求返回大厅的命令。
+func Exit() tea.Msg {
+	return ExitMsg{}
+}
+
+// entry 是一个注册过的游戏和它在默认列表中的位置
+type entry struct {
+	game  Game
+	order int
+}
+
+var (
+	registered []entry // 注册过的所有游戏，按 order 排序
+	games      []Game  // 大厅中启用的游戏
+)
+
+// Register 注册一个可以启用的游戏，通常在游戏的 init 中调用，ID 重复时 panic。
+// order 决定没有配置时大厅中的顺序，小的排在前面，与注册的先后无关。
+func Register(g Game, order int) {
+	for _, r := range registered {
+		if r.game.ID() == g.ID() {
+			panic(fmt.Sprintf("registry: game %q registered twice", g.ID()))
+		}
+	}
+	registered = append(registered, entry{g, order})
+	slices.SortStableFunc(registered, func(a, b entry) int { return a.order - b.order })
+}
+
+// IDs 按 Register 的 order 返回所有注册过的游戏的 ID。
+func IDs() []string {
+	ids := make([]string, len(registered))
+	for i, r := range registered {
+		ids[i] = r.game.ID()
+	}
+	return ids
+}
+
+// Enable 按 ids 的顺序启用游戏，之前启用的游戏不再列出。每个游戏先交给
+// configure，由它返回按服务器配置调整过的游戏。有未注册的 ID 时返回错误。
+func Enable(ids []string, configure func(Game) Game) error {
+	enabled := make([]Game, 0, len(ids))
+	for _, id := range ids {
+		i := slices.IndexFunc(registered, func(r entry) bool { return r.game.ID() == id })
+		if i < 0 {
+			return fmt.Errorf("registry: unknown game %q", id)
+		}
+		enabled = append(enabled, configure(registered[i].game))
+	}
+	games = enabled
+	return nil
+}
+
+// All 按启用的顺序返回大厅中的所有游戏。
+func All() []Game {
+	return append([]Game(nil), games...)
+}
+
+// Lookup 按 ID 查找启用的游戏。
+func Lookup(id string) (Game, bool) {
+	for _, g := range games {
+		if g.ID() == id {
+			return g, true
+		}
+	}
+	return nil, false
+}
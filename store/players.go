@@ -0,0 +1,37 @@
+package store
+
+import (
+	"termiplay/go-backend/player"
+)
+
+const playersFile = "players.json"
+
+// Player 按 ID 查找已保存的玩家。
+func (s *Store) Player(id string) (player.Player, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	players := map[string]player.Player{}
+	if err := s.readJSON(playersFile, &players); err != nil {
+		return player.Player{}, false, err
+	}
+	p, ok := players[id]
+	return p, ok, nil
+}
+
+// SavePlayer 保存或更新玩家信息，游客不会被保存。
+func (s *Store) SavePlayer(p player.Player) error {
+	if p.IsGuest() {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	players := map[string]player.Player{}
+	if err := s.readJSON(playersFile, &players); err != nil {
+		return err
+	}
+	players[p.ID] = p
+	return s.writeJSON(playersFile, players)
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+)
+
+const replaysFile = "replays.jsonl"
+
+// Replay 是一局游戏的录像，Data 的格式由对应的游戏决定。
+type Replay struct {
+	ID string `json:"id"`
+	Result
+	Data json.RawMessage `json:"data"`
+}
+
+// AddReplay 保存一段录像并返回它的编号，游客的录像不会保存。
+func (s *Store) AddReplay(r Replay) (string, error) {
+	if r.PlayerID == "" {
+		return "", nil
+	}
+	if r.ID == "" {
+		r.ID = fmt.Sprintf("%08x", rand.Uint32())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return r.ID, s.appendJSONLine(replaysFile, r)
+}
+
+// Replays 返回满足 keep 的所有录像，最近的排在前面。
+func (s *Store) Replays(keep func(Replay) bool) ([]Replay, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var replays []Replay
+	err := s.readJSONLines(replaysFile, func(line []byte) error {
+		var r Replay
+		if err := json.Unmarshal(line, &r); err != nil {
+			return err
+		}
+		if keep == nil || keep(r) {
+			replays = append(replays, r)
+		}
+		return nil
+	})
+	slices.Reverse(replays)
+	return replays, err
+}
+
+// Replay 按编号查找录像。
+func (s *Store) Replay(id string) (Replay, bool, error) {
+	replays, err := s.Replays(func(r Replay) bool { return r.ID == id })
+	if err != nil || len(replays) == 0 {
+		return Replay{}, false, err
+	}
+	return replays[0], true, nil
+}
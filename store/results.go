@@ -0,0 +1,137 @@
+package store
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"time"
+)
+
+const resultsFile = "results.jsonl"
+
+// Result 记录一局已经结束的游戏。
+type Result struct {
+	PlayerID   string        `json:"player_id"`
+	Nickname   string        `json:"nickname"`
+	Game       string        `json:"game"`
+	Variant    string        `json:"variant,omitempty"` // 例如扫雷的难度
+	Score      int           `json:"score,omitempty"`
+	Duration   time.Duration `json:"duration"`
+	Won        bool          `json:"won"`
+	Seed       int64         `json:"seed"`
+	Daily      string        `json:"daily,omitempty"`     // 每日挑战的日期，普通对局为空
+	Practice   bool          `json:"practice,omitempty"`  // 练习模式的对局，可以随意撤销，不上榜
+	Undos      int           `json:"undos,omitempty"`     // 撤销的次数
+	Continued  bool          `json:"continued,omitempty"` // 拼出目标后选择了继续游戏
+	Hints      int           `json:"hints,omitempty"`     // 使用提示的次数，用过提示的成绩不上榜
+	FinishedAt time.Time     `json:"finished_at"`
+}
+
+// AddResult 追加一条游戏记录，游客的记录不会保存。
+func (s *Store) AddResult(r Result) error {
+	if r.PlayerID == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.appendJSONLine(resultsFile, r)
+}
+
+// Results 返回满足 keep 的所有记录，按结束时间排序。
+func (s *Store) Results(keep func(Result) bool) ([]Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []Result
+	err := s.readJSONLines(resultsFile, func(line []byte) error {
+		var r Result
+		if err := json.Unmarshal(line, &r); err != nil {
+			return err
+		}
+		if keep == nil || keep(r) {
+			results = append(results, r)
+		}
+		return nil
+	})
+	return results, err
+}
+
+// Board 描述一个榜单。
+type Board struct {
+	Game    string
+	Variant string
+	Daily   string // 非空时为该日期的每日挑战榜
+	Fastest bool   // 按胜利用时排序，否则按分数排序
+}
+
+// Leaderboard 返回榜单 b 的前 n 名，每名玩家只保留最好成绩，
+// 用过提示的成绩和练习模式的成绩不上榜。
+// 每日挑战只计算每名玩家当天第一次完成的对局。
+func (s *Store) Leaderboard(b Board, n int) ([]Result, error) {
+	results, err := s.Results(func(r Result) bool {
+		return r.Game == b.Game && r.Variant == b.Variant && r.Daily == b.Daily
+	})
+	if err != nil {
+		return nil, err
+	}
+	if b.Daily != "" {
+		results = firstAttempts(results)
+	}
+	// 每日挑战中用过提示的第一次也算作机会用掉了，所以在 firstAttempts 之后再去掉
+	results = slices.DeleteFunc(results, func(r Result) bool { return r.Hints > 0 || r.Practice })
+
+	if !b.Fastest {
+		return best(results, n, func(a, b Result) bool {
+			return a.Score > b.Score
+		}), nil
+	}
+
+	won := results[:0]
+	for _, r := range results {
+		if r.Won {
+			won = append(won, r)
+		}
+	}
+	return best(won, n, func(a, b Result) bool {
+		return a.Duration < b.Duration
+	}), nil
+}
+
+// firstAttempts 为每名玩家保留最早的一条记录。
+func firstAttempts(results []Result) []Result {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].FinishedAt.Before(results[j].FinishedAt)
+	})
+	seen := make(map[string]bool)
+	first := results[:0]
+	for _, r := range results {
+		if !seen[r.PlayerID] {
+			seen[r.PlayerID] = true
+			first = append(first, r)
+		}
+	}
+	return first
+}
+
+// best 按 better 排序后为每名玩家保留第一条记录，最多返回 n 条。
+func best(results []Result, n int, better func(a, b Result) bool) []Result {
+	sort.SliceStable(results, func(i, j int) bool {
+		return better(results[i], results[j])
+	})
+
+	seen := make(map[string]bool)
+	top := make([]Result, 0, n)
+	for _, r := range results {
+		if len(top) == n {
+			break
+		}
+		if seen[r.PlayerID] {
+			continue
+		}
+		seen[r.PlayerID] = true
+		top = append(top, r)
+	}
+	return top
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLeaderboardSkipsPractice(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	results := []Result{
+		{PlayerID: "ranked", Game: "2048", Score: 1000, FinishedAt: now},
+		{PlayerID: "practice", Game: "2048", Score: 50000, Practice: true, Undos: 30, FinishedAt: now},
+		{PlayerID: "hinted", Game: "2048", Score: 20000, Hints: 1, FinishedAt: now},
+	}
+	for _, r := range results {
+		if err := s.AddResult(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	top, err := s.Leaderboard(Board{Game: "2048"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 1 || top[0].PlayerID != "ranked" {
+		t.Errorf("Leaderboard = %+v, want only the ranked result", top)
+	}
+}
+
+func TestResultsSkipMalformedLines(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddResult(Result{PlayerID: "before", Game: "2048"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟写到一半时进程被杀掉留下的半行
+	f, err := os.OpenFile(filepath.Join(dir, resultsFile), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"player_id":"trunc` + "\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if err := s.AddResult(Result{PlayerID: "after", Game: "2048"}); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := s.Results(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].PlayerID != "before" || results[1].PlayerID != "after" {
+		t.Errorf("Results = %+v, want the two complete results", results)
+	}
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+const savesFile = "saves.json"
+
+// Save 是一局未完成游戏的存档，每名玩家每个游戏（以及每个每日挑战）只有一个存档。
+type Save struct {
+	Game    string          `json:"game"`
+	Daily   string          `json:"daily,omitempty"`
+	Data    json.RawMessage `json:"data"`
+	SavedAt time.Time       `json:"saved_at"`
+}
+
+func (sv Save) key() string {
+	if sv.Daily == "" {
+		return sv.Game
+	}
+	return sv.Game + "/" + sv.Daily
+}
+
+// PutSave 为玩家保存存档，覆盖同一个游戏之前的存档。游客的存档不会保存。
+func (s *Store) PutSave(playerID string, sv Save) error {
+	if playerID == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saves := map[string]map[string]Save{}
+	if err := s.readJSON(savesFile, &saves); err != nil {
+		return err
+	}
+	if saves[playerID] == nil {
+		saves[playerID] = make(map[string]Save)
+	}
+	saves[playerID][sv.key()] = sv
+	return s.writeJSON(savesFile, saves)
+}
+
+// Saves 返回玩家的所有存档，最近保存的排在前面。
+func (s *Store) Saves(playerID string) ([]Save, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saves := map[string]map[string]Save{}
+	if err := s.readJSON(savesFile, &saves); err != nil {
+		return nil, err
+	}
+
+	var list []Save
+	for _, sv := range saves[playerID] {
+		list = append(list, sv)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].SavedAt.After(list[j].SavedAt)
+	})
+	return list, nil
+}
+
+// DeleteSave 删除玩家在 game 中的存档，daily 为每日挑战的日期。
+func (s *Store) DeleteSave(playerID, game, daily string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saves := map[string]map[string]Save{}
+	if err := s.readJSON(savesFile, &saves); err != nil {
+		return err
+	}
+	key := Save{Game: game, Daily: daily}.key()
+	if _, ok := saves[playerID][key]; !ok {
+		return nil
+	}
+	delete(saves[playerID], key)
+	return s.writeJSON(savesFile, saves)
+}
@@ -0,0 +1,102 @@
+// Package store 把玩家数据以 JSON 文件的形式保存在本地数据目录中。
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/charmbracelet/log"
+)
+
+// Store 是一个基于文件的存储，所有方法都可以被多个会话同时调用。
+type Store struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// Open 打开 dir 目录下的存储，目录不存在时会自动创建。
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// readJSON 把文件 name 解码到 v 中，文件不存在时保持 v 不变。
+// 调用方必须持有 s.mu。
+func (s *Store) readJSON(name string, v any) error {
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeJSON 先写入临时文件再重命名，避免写到一半的文件被读到。
+// 调用方必须持有 s.mu。
+func (s *Store) writeJSON(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// appendJSONLine 把 v 编码为一行 JSON 追加到文件 name 末尾。
+// 调用方必须持有 s.mu。
+func (s *Store) appendJSONLine(name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(s.dir, name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readJSONLines 依次把文件 name 的每一行交给 fn，文件不存在时什么也不做。
+// fn 返回错误的行会记录到日志并跳过，例如写到一半时进程被杀掉留下的半行，
+// 这样一行坏数据不会让整个文件都读不出来。
+// 调用方必须持有 s.mu。
+func (s *Store) readJSONLines(name string, fn func(line []byte) error) error {
+	f, err := os.Open(filepath.Join(s.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	for n := 1; scanner.Scan(); n++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if err := fn(scanner.Bytes()); err != nil {
+			log.Warn("Skipping malformed line", "file", name, "line", n, "error", err)
+		}
+	}
+	return scanner.Err()
+}